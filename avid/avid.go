@@ -0,0 +1,61 @@
+// Package avid works with AV ids ({BLDG}-{Room}-{Device}) and the random ids
+// given to webhooks, schedules and events.
+package avid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Building returns the building abbreviation in a room, device, or resource id
+func Building(id string) string {
+	return strings.SplitN(id, "-", 2)[0]
+}
+
+// Room returns the room id ({BLDG}-{Room}) in a device or resource id
+func Room(id string) string {
+	parts := strings.SplitN(id, "-", 3)
+	if len(parts) < 2 {
+		return id
+	}
+
+	return parts[0] + "-" + parts[1]
+}
+
+// Random returns a random hex id made from n bytes
+func Random(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("unable to generate random id: %s", err))
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Contains reports whether list has s in it
+func Contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Equal reports whether a and b have the same ids in the same order
+func Equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
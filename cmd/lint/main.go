@@ -0,0 +1,68 @@
+// Command lint checks every room's ui-configuration against the devices and
+// device types in couch, printing the dangling references, duplicates and
+// missing documents it finds. It exits with 1 if there are any.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/services"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	var dbAddress string
+	var dbUsername string
+	var dbPassword string
+	var building string
+	var asJSON bool
+
+	pflag.StringVar(&dbAddress, "db-address", os.Getenv("DB_ADDRESS"), "address to the couch db")
+	pflag.StringVar(&dbUsername, "db-username", "", "username for the couch db")
+	pflag.StringVar(&dbPassword, "db-password", "", "password for the couch db")
+	pflag.StringVarP(&building, "building", "b", "", "only check the rooms in this building")
+	pflag.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	pflag.Parse()
+
+	if dbAddress == "" {
+		fmt.Fprintln(os.Stderr, "--db-address (or DB_ADDRESS) is required")
+		os.Exit(2)
+	}
+
+	s := &services.Service{
+		DB: &db.Service{
+			Address:  dbAddress,
+			Username: dbUsername,
+			Password: dbPassword,
+		},
+	}
+
+	report, err := s.LintConfig(building)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to lint configuration: %s\n", err)
+		os.Exit(2)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ROOM\tKIND\tFIELD\tMESSAGE")
+		for _, issue := range report.Issues {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", issue.RoomID, issue.Kind, issue.Field, issue.Message)
+		}
+		w.Flush()
+
+		fmt.Printf("\n%d issues in %d rooms\n", len(report.Issues), report.RoomsChecked)
+	}
+
+	if len(report.Issues) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,50 @@
+// Command simulator serves stand-ins for Couch and the AV API so the
+// translator can be run without access to the production services.
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/simulator"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+func main() {
+	var couchPort int
+	var avAPIPort int
+	var fixtures string
+
+	pflag.IntVar(&couchPort, "couch-port", 5984, "port to serve the simulated couch on")
+	pflag.IntVar(&avAPIPort, "av-api-port", 8000, "port to serve the simulated AV API on")
+	pflag.StringVar(&fixtures, "fixtures", "", "directory to load fixtures from instead of the built in ones")
+	pflag.Parse()
+
+	var fsys fs.FS
+	if fixtures != "" {
+		fsys = os.DirFS(fixtures)
+	} else {
+		fsys = simulator.DefaultFixtures()
+	}
+
+	couch := simulator.NewCouch()
+	avapi := simulator.NewAVAPI()
+	if err := simulator.Load(fsys, couch, avapi); err != nil {
+		log.Log.Fatal("unable to load fixtures", zap.Error(err))
+	}
+
+	errs := make(chan error, 2)
+	serve := func(name string, port int, h http.Handler) {
+		log.Log.Infof("Serving simulated %s on :%d", name, port)
+		errs <- http.ListenAndServe(fmt.Sprintf(":%d", port), h)
+	}
+
+	go serve("couch", couchPort, couch)
+	go serve("AV API", avAPIPort, avapi)
+
+	log.Log.Fatal("simulator stopped", zap.Error(<-errs))
+}
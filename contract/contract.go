@@ -0,0 +1,187 @@
+// Package contract checks live handlers against the OpenAPI spec by
+// making requests and validating the responses against the declared schemas.
+package contract
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/labstack/echo"
+)
+
+// Case is a request used to exercise one operation in the spec
+type Case struct {
+	// Name describes the case, defaulting to the operation id
+	Name        string
+	OperationID string
+
+	// Path is the concrete path to request, e.g. /rooms/ITB-1101
+	Path string
+	Body string
+
+	// Header is added to the request, e.g. an If-Match for writes that require one
+	Header map[string]string
+
+	// Status is the expected response status, defaulting to 200
+	Status int
+
+	// Timeout cancels the request after the given duration. It is
+	// needed for operations that stream, like server-sent events.
+	Timeout time.Duration
+
+	// Sparse allows required properties to be missing from the response,
+	// as they are when only some fields are asked for
+	Sparse bool
+}
+
+// Result is the outcome of running a single case
+type Result struct {
+	Case   Case
+	Status int
+	Errors []string
+}
+
+// Passed reports whether the response matched the spec and the case
+func (r Result) Passed() bool {
+	return len(r.Errors) == 0
+}
+
+func (r Result) String() string {
+	name := r.Case.Name
+	if name == "" {
+		name = r.Case.OperationID
+	}
+
+	if r.Passed() {
+		return fmt.Sprintf("PASS %s (%s)", name, r.Case.Path)
+	}
+
+	return fmt.Sprintf("FAIL %s (%s)\n\t%s", name, r.Case.Path, strings.Join(r.Errors, "\n\t"))
+}
+
+// Uncovered returns the operation ids in the spec that none of cases exercise
+func Uncovered(spec *openapi.Spec, cases []Case) []string {
+	covered := map[string]bool{}
+	for _, c := range cases {
+		covered[c.OperationID] = true
+	}
+
+	var missing []string
+	for _, id := range spec.OperationIDs() {
+		if !covered[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
+// Run makes each case's request against h and checks the response
+func Run(spec *openapi.Spec, h http.Handler, cases []Case) []Result {
+	results := make([]Result, len(cases))
+	for i, c := range cases {
+		results[i] = run(spec, h, c)
+	}
+
+	return results
+}
+
+func run(spec *openapi.Spec, h http.Handler, c Case) Result {
+	res := Result{Case: c}
+
+	method, _, op := spec.OperationByID(c.OperationID)
+	if op == nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("operation %q is not in the spec", c.OperationID))
+		return res
+	}
+
+	req := httptest.NewRequest(method, c.Path, strings.NewReader(c.Body))
+	if c.Body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+
+	for k, v := range c.Header {
+		req.Header.Set(k, v)
+	}
+
+	if c.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), c.Timeout)
+		defer cancel()
+
+		req = req.WithContext(ctx)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	res.Status = rec.Code
+
+	want := c.Status
+	if want == 0 {
+		want = http.StatusOK
+	}
+
+	if rec.Code != want {
+		res.Errors = append(res.Errors, fmt.Sprintf("got status %d, expected %d: %s", rec.Code, want, strings.TrimSpace(rec.Body.String())))
+		return res
+	}
+
+	declared, ok := op.Responses[strconv.Itoa(rec.Code)]
+	if !ok {
+		declared, ok = op.Responses["default"]
+	}
+
+	if !ok {
+		res.Errors = append(res.Errors, fmt.Sprintf("status %d is not declared for %s", rec.Code, c.OperationID))
+		return res
+	}
+
+	// only the content type of other media types is checked
+	ct := rec.Header().Get(echo.HeaderContentType)
+	if !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+		for name := range declared.Content {
+			if name != echo.MIMEApplicationJSON && strings.HasPrefix(ct, name) {
+				return res
+			}
+		}
+	}
+
+	mt, ok := declared.Content[echo.MIMEApplicationJSON]
+	if !ok {
+		for name := range declared.Content {
+			if strings.HasPrefix(ct, name) {
+				return res
+			}
+		}
+
+		if len(declared.Content) > 0 {
+			res.Errors = append(res.Errors, fmt.Sprintf("got content type %q, which is not declared for %s", ct, c.OperationID))
+		}
+
+		return res
+	}
+
+	if mt.Schema == nil {
+		return res
+	}
+
+	if !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+		res.Errors = append(res.Errors, fmt.Sprintf("got content type %q, expected %s", ct, echo.MIMEApplicationJSON))
+		return res
+	}
+
+	for _, err := range openapi.ValidateBody(mt.Schema, "response", rec.Body.Bytes()) {
+		if c.Sparse && err.Reason == "is required" {
+			continue
+		}
+
+		res.Errors = append(res.Errors, err.Error())
+	}
+
+	return res
+}
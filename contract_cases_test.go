@@ -0,0 +1,221 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/contract"
+)
+
+// anyVersion lets a write that requires an If-Match change whatever version is current
+var anyVersion = map[string]string{"If-Match": "*"}
+
+// contractCases exercise every operation in the spec against the simulator's default fixtures
+var contractCases = []contract.Case{
+	// Rooms
+	{OperationID: "get-rooms", Path: "/rooms"},
+	{Name: "get-rooms by building", OperationID: "get-rooms", Path: "/rooms?building_abbreviation=ITB"},
+	{Name: "get-rooms by room", OperationID: "get-rooms", Path: "/rooms?building_abbreviation=ITB&room_number=1101"},
+	{Name: "get-rooms unknown building", OperationID: "get-rooms", Path: "/rooms?building_abbreviation=XYZ"},
+	{Name: "get-rooms pattern room", OperationID: "get-rooms", Path: "/rooms?room_number=.*"},
+	{Name: "get-rooms several buildings", OperationID: "get-rooms", Path: "/rooms?building_abbreviation=ITB,JKBX"},
+	{Name: "get-rooms by designation", OperationID: "get-rooms", Path: "/rooms?designation=stage"},
+	{Name: "get-rooms bad designation", OperationID: "get-rooms", Path: "/rooms?designation=bogus", Status: http.StatusBadRequest},
+	{Name: "get-rooms by tag", OperationID: "get-rooms", Path: "/rooms?tag=description:Annex%20lab"},
+	{Name: "get-rooms search", OperationID: "get-rooms", Path: "/rooms?search=lecture"},
+	{Name: "get-rooms by resource", OperationID: "get-rooms", Path: "/rooms?has_resource=Microphone"},
+	{Name: "get-rooms fields", OperationID: "get-rooms", Path: "/rooms?fields=av_room_id,room_number", Sparse: true},
+	{Name: "get-rooms sorted", OperationID: "get-rooms", Path: "/rooms?sort=-building_abbreviation,room_number"},
+	{Name: "get-rooms bad sort", OperationID: "get-rooms", Path: "/rooms?sort=room%20number", Status: http.StatusBadRequest},
+	{Name: "get-rooms expanded", OperationID: "get-rooms", Path: "/rooms?building_abbreviation=ITB&expand=devices,state"},
+	{Name: "get-rooms bad expand", OperationID: "get-rooms", Path: "/rooms?expand=config", Status: http.StatusBadRequest},
+	{OperationID: "get-rooms-room_id", Path: "/rooms/ITB-1101"},
+	{Name: "get-rooms-room_id expanded", OperationID: "get-rooms-room_id", Path: "/rooms/ITB-1101?expand=devices,state"},
+	{Name: "get-rooms-room_id invalid id", OperationID: "get-rooms-room_id", Path: "/rooms/ITB1101", Status: http.StatusBadRequest},
+	{OperationID: "get-rooms-room_id-devices", Path: "/rooms/ITB-1101/devices"},
+
+	// Buildings
+	{OperationID: "get-buildings", Path: "/buildings"},
+	{Name: "get-buildings search", OperationID: "get-buildings", Path: "/buildings?search=knight"},
+	{Name: "get-buildings paged", OperationID: "get-buildings", Path: "/buildings?offset=1&limit=1"},
+	{Name: "get-buildings sorted and paged", OperationID: "get-buildings", Path: "/buildings?sort=-room_count&limit=2"},
+	{Name: "get-buildings bad limit", OperationID: "get-buildings", Path: "/buildings?limit=0", Status: http.StatusBadRequest},
+	{OperationID: "get-buildings-building_abbreviation", Path: "/buildings/ITB"},
+	{Name: "get-buildings-building_abbreviation without document", OperationID: "get-buildings-building_abbreviation", Path: "/buildings/JKBX"},
+	{Name: "get-buildings-building_abbreviation unknown", OperationID: "get-buildings-building_abbreviation", Path: "/buildings/XYZ", Status: http.StatusNotFound},
+	{OperationID: "get-buildings-building_abbreviation-rooms", Path: "/buildings/ITB/rooms"},
+	{Name: "get-buildings-building_abbreviation-rooms by room", OperationID: "get-buildings-building_abbreviation-rooms", Path: "/buildings/ITB/rooms?room_number=1108"},
+	{Name: "get-buildings-building_abbreviation-rooms unknown", OperationID: "get-buildings-building_abbreviation-rooms", Path: "/buildings/XYZ/rooms", Status: http.StatusNotFound},
+
+	// Devices
+	{OperationID: "get-devices", Path: "/devices"},
+	{Name: "get-devices by type", OperationID: "get-devices", Path: "/devices?building_abbreviation=ITB&av_device_type=EpsonProjector"},
+	{Name: "get-devices unknown building", OperationID: "get-devices", Path: "/devices?building_abbreviation=XYZ"},
+	{Name: "get-devices building prefix", OperationID: "get-devices", Path: "/devices?building_abbreviation=JK"},
+	{Name: "get-devices by role", OperationID: "get-devices", Path: "/devices?role=VideoOut&building_abbreviation=ITB"},
+	{Name: "get-devices by tag", OperationID: "get-devices", Path: "/devices?tag=location:front"},
+	{Name: "get-devices by several tags", OperationID: "get-devices", Path: "/devices?tag=location:front&tag=location"},
+	{Name: "get-devices bad tag", OperationID: "get-devices", Path: "/devices?tag=location:front&tag=:front", Status: http.StatusBadRequest},
+	{Name: "get-devices search", OperationID: "get-devices", Path: "/devices?search=projector"},
+	{Name: "get-devices fields", OperationID: "get-devices", Path: "/devices?fields=av_device_id&fields=av_device_type", Sparse: true},
+	{Name: "get-devices sorted", OperationID: "get-devices", Path: "/devices?sort=-av_device_type"},
+	{Name: "get-devices unknown type", OperationID: "get-devices", Path: "/devices?av_device_type=Epson.*", Status: http.StatusBadRequest},
+	{OperationID: "get-devices-device_id", Path: "/devices/ITB-1101-D1"},
+	{OperationID: "get-devices-av_device_id-properties", Path: "/devices/ITB-1101-D1/properties"},
+	{OperationID: "get-devices-av_device_id-properties-devices-av_device_id-state", Path: "/devices/ITB-1101-D1/state"},
+
+	// Device Types
+	{OperationID: "get-device_types", Path: "/device_types"},
+	{OperationID: "get-device_types-av_device_type_id", Path: "/device_types/SonyXBR"},
+	{Name: "get-device_types-av_device_type_id unknown", OperationID: "get-device_types-av_device_type_id", Path: "/device_types/Unknown", Status: http.StatusNotFound},
+
+	// Inputs
+	{OperationID: "get-inputs", Path: "/inputs"},
+	{Name: "get-inputs by room", OperationID: "get-inputs", Path: "/inputs?building_abbreviation=ITB&room_number=1101"},
+	{Name: "get-inputs unknown building", OperationID: "get-inputs", Path: "/inputs?building_abbreviation=XYZ"},
+	{OperationID: "get-inputs-av_device_id", Path: "/inputs/ITB-1101-VIA1"},
+
+	// Displays
+	{OperationID: "get-displays", Path: "/displays"},
+	{Name: "get-displays by building", OperationID: "get-displays", Path: "/displays?building_abbreviation=JKB"},
+	{Name: "get-displays unknown building", OperationID: "get-displays", Path: "/displays?building_abbreviation=XYZ"},
+	{Name: "get-displays several buildings", OperationID: "get-displays", Path: "/displays?building_abbreviation=ITB&building_abbreviation=JKB"},
+	{Name: "get-displays expanded", OperationID: "get-displays", Path: "/displays?expand=config,state"},
+	{Name: "get-displays expanded fields", OperationID: "get-displays", Path: "/displays?expand=config&fields=av_display_id", Sparse: true},
+	{Name: "get-displays sorted by state", OperationID: "get-displays", Path: "/displays?expand=state&sort=av_display_state.av_display_powered"},
+	{OperationID: "get-displays-av_display_id", Path: "/displays/ITB-1101-Display1"},
+	{OperationID: "get-displays-av_display_id-config", Path: "/displays/ITB-1101-Display2"},
+	{OperationID: "get-displays-av_display_id-state", Path: "/displays/ITB-1101-Display1/state"},
+	{OperationID: "post-displays-state-batchGet", Path: "/displays/state:batchGet", Body: `{"av_display_ids":["ITB-1101-Display1","ITB-1101-Display2","ITB-1108-Display1","ITB-1101-Display9","XYZ-100-Display1","bad"]}`},
+	{Name: "post-displays-state-batchGet empty", OperationID: "post-displays-state-batchGet", Path: "/displays/state:batchGet", Body: `{"av_display_ids":[]}`, Status: http.StatusBadRequest},
+
+	// Audio Outputs
+	{OperationID: "get-audio_outputs", Path: "/audio_outputs"},
+	{Name: "get-audio_outputs unknown building", OperationID: "get-audio_outputs", Path: "/audio_outputs?building_abbreviation=XYZ"},
+	{OperationID: "get-audio_outputs-device_id", Path: "/audio_outputs/ITB-1101-MasterAudio1"},
+	{Name: "get-audio_outputs-device_id independent", OperationID: "get-audio_outputs-device_id", Path: "/audio_outputs/ITB-1101-MIC1"},
+	{Name: "get-audio_outputs-device_id expanded", OperationID: "get-audio_outputs-device_id", Path: "/audio_outputs/ITB-1101-MIC1?expand=state"},
+	{OperationID: "get-audio_outputs-av_audio_output_id-state", Path: "/audio_outputs/ITB-1101-MasterAudio1/state"},
+	{Name: "get-audio_outputs-av_audio_output_id-state independent", OperationID: "get-audio_outputs-av_audio_output_id-state", Path: "/audio_outputs/ITB-1101-MIC2/state"},
+	{OperationID: "get-displays-av_display_id-state-history", Path: "/displays/ITB-1101-Display1/state/history?from=2020-01-01T00:00:00Z&to=2020-01-02T00:00:00Z"},
+	{Name: "get-displays-av_display_id-state-history default range", OperationID: "get-displays-av_display_id-state-history", Path: "/displays/ITB-1101-Display1/state/history"},
+	{Name: "get-displays-av_display_id-state-history not recorded", OperationID: "get-displays-av_display_id-state-history", Path: "/displays/ITB-1108-Display1/state/history", Status: http.StatusNotFound},
+	{Name: "get-displays-av_display_id-state-history backwards", OperationID: "get-displays-av_display_id-state-history", Path: "/displays/ITB-1101-Display1/state/history?from=2020-01-02T00:00:00Z&to=2020-01-01T00:00:00Z", Status: http.StatusBadRequest},
+	{OperationID: "get-devices-av_device_id-health", Path: "/devices/ITB-1101-D1/health"},
+	{Name: "get-devices-av_device_id-health unreachable", OperationID: "get-devices-av_device_id-health", Path: "/devices/ITB-1101-D2/health"},
+	{Name: "get-devices-av_device_id-health not checked", OperationID: "get-devices-av_device_id-health", Path: "/devices/ITB-1101-XYZ1/health", Status: http.StatusNotFound},
+	{Name: "get-devices-av_device_id-health not monitored", OperationID: "get-devices-av_device_id-health", Path: "/devices/JKB-1106-D1/health", Status: http.StatusNotFound},
+	{OperationID: "get-health-summary", Path: "/health/summary"},
+	{OperationID: "get-admin-lint", Path: "/admin/lint"},
+	{Name: "get-admin-lint building", OperationID: "get-admin-lint", Path: "/admin/lint?building_abbreviation=ITB"},
+	{OperationID: "get-rooms-room_id-usage", Path: "/rooms/ITB-1101/usage?from=2020-01-01T00:00:00Z&to=2020-01-02T00:00:00Z"},
+	{Name: "get-rooms-room_id-usage csv", OperationID: "get-rooms-room_id-usage", Path: "/rooms/ITB-1101/usage?from=2020-01-01T00:00:00Z&to=2020-01-02T00:00:00Z&format=csv"},
+	{Name: "get-rooms-room_id-usage not recorded", OperationID: "get-rooms-room_id-usage", Path: "/rooms/ITB-1108/usage", Status: http.StatusNotFound},
+	{OperationID: "get-buildings-building_abbreviation-usage", Path: "/buildings/ITB/usage?from=2020-01-01T00:00:00Z&to=2020-01-02T00:00:00Z"},
+	{Name: "get-buildings-building_abbreviation-usage csv", OperationID: "get-buildings-building_abbreviation-usage", Path: "/buildings/ITB/usage?format=csv"},
+	{Name: "get-buildings-building_abbreviation-usage not recorded", OperationID: "get-buildings-building_abbreviation-usage", Path: "/buildings/JFSB/usage", Status: http.StatusNotFound},
+	{OperationID: "get-audio_outputs-av_audio_output_id-state-history", Path: "/audio_outputs/ITB-1101-MasterAudio1/state/history?from=2020-01-01T00:00:00Z&to=2020-01-02T00:00:00Z"},
+	{OperationID: "post-audio_outputs-state-batchGet", Path: "/audio_outputs/state:batchGet", Body: `{"av_audio_output_ids":["ITB-1101-MasterAudio1","ITB-1101-MIC2","ITB-1108-MasterAudio1","XYZ-100-MIC1"]}`},
+
+	// Microphones
+	{OperationID: "get-microphones", Path: "/microphones"},
+	{Name: "get-microphones by room", OperationID: "get-microphones", Path: "/microphones?building_abbreviation=ITB&room_number=1101"},
+	{OperationID: "get-microphones-av_microphone_id", Path: "/microphones/ITB-1101-MIC1"},
+	{Name: "get-microphones-av_microphone_id not a microphone", OperationID: "get-microphones-av_microphone_id", Path: "/microphones/ITB-1101-D1", Status: http.StatusNotFound},
+	{OperationID: "get-microphones-av_microphone_id-state", Path: "/microphones/ITB-1101-MIC1/state"},
+	{Name: "get-microphones-av_microphone_id-state unknown", OperationID: "get-microphones-av_microphone_id-state", Path: "/microphones/XYZ-100-MIC1/state", Status: http.StatusNotFound},
+	{OperationID: "put-microphones-av_microphone_id-state", Path: "/microphones/ITB-1101-MIC1/state", Body: `{"av_microphone_muted":true,"av_microphone_volume_level":40}`, Header: anyVersion},
+	{Name: "put-microphones-av_microphone_id-state without if-match", OperationID: "put-microphones-av_microphone_id-state", Path: "/microphones/ITB-1101-MIC1/state", Body: `{"av_microphone_muted":true}`, Status: http.StatusPreconditionRequired},
+	{Name: "put-microphones-av_microphone_id-state bad volume", OperationID: "put-microphones-av_microphone_id-state", Path: "/microphones/ITB-1101-MIC1/state", Body: `{"av_microphone_volume_level":101}`, Header: anyVersion, Status: http.StatusBadRequest},
+	{Name: "put-microphones-av_microphone_id-state empty", OperationID: "put-microphones-av_microphone_id-state", Path: "/microphones/ITB-1101-MIC1/state", Body: `{}`, Header: anyVersion, Status: http.StatusBadRequest},
+
+	// Groups
+	{OperationID: "get-rooms-room_id-groups", Path: "/rooms/ITB-1101/groups"},
+	{Name: "get-rooms-room_id-groups unknown room", OperationID: "get-rooms-room_id-groups", Path: "/rooms/XYZ-100/groups", Status: http.StatusNotFound},
+	{OperationID: "put-rooms-room_id-groups-av_display_id", Path: "/rooms/ITB-1101/groups/ITB-1101-Display1", Body: `{"av_shared_display_ids":["ITB-1101-Display2"]}`, Header: anyVersion},
+	{Name: "put-rooms-room_id-groups-av_display_id unshare", OperationID: "put-rooms-room_id-groups-av_display_id", Path: "/rooms/ITB-1101/groups/ITB-1101-Display1", Body: `{"av_shared_display_ids":[]}`, Header: anyVersion},
+	{Name: "put-rooms-room_id-groups-av_display_id without if-match", OperationID: "put-rooms-room_id-groups-av_display_id", Path: "/rooms/ITB-1101/groups/ITB-1101-Display1", Body: `{"av_shared_display_ids":[]}`, Status: http.StatusPreconditionRequired},
+	{Name: "put-rooms-room_id-groups-av_display_id not shareable", OperationID: "put-rooms-room_id-groups-av_display_id", Path: "/rooms/ITB-1101/groups/ITB-1101-Display1", Body: `{"av_shared_display_ids":["ITB-1108-Display1"]}`, Header: anyVersion, Status: http.StatusBadRequest},
+	{Name: "put-rooms-room_id-groups-av_display_id off", OperationID: "put-rooms-room_id-groups-av_display_id", Path: "/rooms/ITB-1101/groups/ITB-1101-Display2", Body: `{"av_shared_display_ids":["ITB-1101-Display1"]}`, Header: anyVersion, Status: http.StatusConflict},
+	{Name: "put-rooms-room_id-groups-av_display_id unknown display", OperationID: "put-rooms-room_id-groups-av_display_id", Path: "/rooms/ITB-1101/groups/ITB-1101-Display9", Body: `{"av_shared_display_ids":[]}`, Header: anyVersion, Status: http.StatusNotFound},
+
+	// Scenes
+	{OperationID: "get-rooms-room_id-scenes", Path: "/rooms/ITB-1101/scenes"},
+	{Name: "get-rooms-room_id-scenes none", OperationID: "get-rooms-room_id-scenes", Path: "/rooms/ITB-1108/scenes"},
+	{Name: "get-rooms-room_id-scenes unknown room", OperationID: "get-rooms-room_id-scenes", Path: "/rooms/XYZ-100/scenes", Status: http.StatusNotFound},
+	{OperationID: "post-rooms-room_id-scenes-scene-apply", Path: "/rooms/ITB-1101/scenes/Lecture%20mode:apply"},
+	{Name: "post-rooms-room_id-scenes-scene-apply unknown scene", OperationID: "post-rooms-room_id-scenes-scene-apply", Path: "/rooms/ITB-1101/scenes/Movie:apply", Status: http.StatusNotFound},
+	{OperationID: "put-rooms-room_id-scenes", Path: "/rooms/ITB-1108/scenes", Body: `[{"av_scene_name":"Off","av_displays":[{"av_display_id":"ITB-1108-Display1","av_display_powered":false}]}]`, Header: anyVersion},
+	{Name: "put-rooms-room_id-scenes without if-match", OperationID: "put-rooms-room_id-scenes", Path: "/rooms/ITB-1108/scenes", Body: `[]`, Status: http.StatusPreconditionRequired},
+	{Name: "put-rooms-room_id-scenes stale", OperationID: "put-rooms-room_id-scenes", Path: "/rooms/ITB-1108/scenes", Body: `[]`, Header: map[string]string{"If-Match": `"1-stale"`}, Status: http.StatusPreconditionFailed},
+	{Name: "put-rooms-room_id-scenes unknown input", OperationID: "put-rooms-room_id-scenes", Path: "/rooms/ITB-1101/scenes", Body: `[{"av_scene_name":"Lecture mode","av_displays":[{"av_display_id":"ITB-1101-Display2","av_display_input":"ITB-1101-VIA1"}]}]`, Header: anyVersion, Status: http.StatusBadRequest},
+	{Name: "put-rooms-room_id-scenes duplicate name", OperationID: "put-rooms-room_id-scenes", Path: "/rooms/ITB-1101/scenes", Body: `[{"av_scene_name":"A","av_displays":[{"av_display_id":"ITB-1101-Display1"}]},{"av_scene_name":"A","av_displays":[{"av_display_id":"ITB-1101-Display2"}]}]`, Header: anyVersion, Status: http.StatusBadRequest},
+
+	// Cameras
+	{OperationID: "get-cameras", Path: "/cameras"},
+	{Name: "get-cameras by room", OperationID: "get-cameras", Path: "/cameras?building_abbreviation=ITB&room_number=1101"},
+	{Name: "get-cameras search", OperationID: "get-cameras", Path: "/cameras?search=back"},
+	{OperationID: "get-cameras-av_camera_id", Path: "/cameras/ITB-1101-Camera1"},
+	{Name: "get-cameras-av_camera_id unknown", OperationID: "get-cameras-av_camera_id", Path: "/cameras/ITB-1101-Camera9", Status: http.StatusNotFound},
+	{OperationID: "post-cameras-av_camera_id-control", Path: "/cameras/ITB-1101-Camera1/control", Body: `{"av_camera_preset":"Whiteboard"}`, Status: http.StatusNoContent},
+	{Name: "post-cameras-av_camera_id-control command", OperationID: "post-cameras-av_camera_id-control", Path: "/cameras/ITB-1101-Camera2/control", Body: `{"av_camera_command":"zoom_in"}`, Status: http.StatusNoContent},
+	{Name: "post-cameras-av_camera_id-control unknown preset", OperationID: "post-cameras-av_camera_id-control", Path: "/cameras/ITB-1101-Camera2/control", Body: `{"av_camera_preset":"Whiteboard"}`, Status: http.StatusBadRequest},
+	{Name: "post-cameras-av_camera_id-control bad command", OperationID: "post-cameras-av_camera_id-control", Path: "/cameras/ITB-1101-Camera1/control", Body: `{"av_camera_command":"spin"}`, Status: http.StatusBadRequest},
+	{Name: "post-cameras-av_camera_id-control unknown camera", OperationID: "post-cameras-av_camera_id-control", Path: "/cameras/XYZ-100-Camera1/control", Body: `{"av_camera_command":"zoom_in"}`, Status: http.StatusNotFound},
+
+	// Events
+	{OperationID: "get-rooms-room_id-events", Path: "/rooms/ITB-1101/events", Timeout: 500 * time.Millisecond},
+	{Name: "get-rooms-room_id-events unknown room", OperationID: "get-rooms-room_id-events", Path: "/rooms/XYZ-100/events", Status: http.StatusNotFound},
+	{OperationID: "get-buildings-building_abbreviation-events", Path: "/buildings/JKB/events", Timeout: 500 * time.Millisecond},
+	{Name: "get-buildings-building_abbreviation-events unknown building", OperationID: "get-buildings-building_abbreviation-events", Path: "/buildings/XYZ/events", Status: http.StatusNotFound},
+}
+
+// webhookCases exercise the webhook operations against an already registered webhook, which they delete
+func webhookCases(id string) []contract.Case {
+	hook := "/webhooks/" + id
+	body := `{"url":"http://localhost:9/hook","filters":{"av_room_ids":["ITB-1101"],"resource_types":["display"],"attributes":["av_display_powered=true"]}}`
+
+	return []contract.Case{
+		{OperationID: "get-webhooks", Path: "/webhooks"},
+		{OperationID: "post-webhooks", Path: "/webhooks", Body: body, Status: http.StatusCreated},
+		{Name: "post-webhooks bad url", OperationID: "post-webhooks", Path: "/webhooks", Body: `{"url":"ftp://localhost/hook"}`, Status: http.StatusBadRequest},
+		{Name: "post-webhooks missing url", OperationID: "post-webhooks", Path: "/webhooks", Body: `{"filters":{}}`, Status: http.StatusBadRequest},
+		{Name: "post-webhooks state from every room", OperationID: "post-webhooks", Path: "/webhooks", Body: `{"url":"http://localhost:9/hook","filters":{"resource_types":["display"]}}`, Status: http.StatusBadRequest},
+		{Name: "post-webhooks config from every room", OperationID: "post-webhooks", Path: "/webhooks", Body: `{"url":"http://localhost:9/hook","filters":{"resource_types":["room","device"]}}`, Status: http.StatusCreated},
+		{Name: "post-webhooks bad resource type", OperationID: "post-webhooks", Path: "/webhooks", Body: `{"url":"http://localhost:9/hook","filters":{"resource_types":["projector"]}}`, Status: http.StatusBadRequest},
+		{OperationID: "get-webhooks-webhook_id", Path: hook},
+		{Name: "get-webhooks-webhook_id unknown", OperationID: "get-webhooks-webhook_id", Path: "/webhooks/unknown", Status: http.StatusNotFound},
+		{OperationID: "put-webhooks-webhook_id", Path: hook, Body: body, Header: anyVersion},
+		{Name: "put-webhooks-webhook_id without if-match", OperationID: "put-webhooks-webhook_id", Path: hook, Body: body, Status: http.StatusPreconditionRequired},
+		{Name: "put-webhooks-webhook_id stale", OperationID: "put-webhooks-webhook_id", Path: hook, Body: body, Header: map[string]string{"If-Match": `"stale"`}, Status: http.StatusPreconditionFailed},
+		{OperationID: "get-webhooks-webhook_id-dead_letters", Path: hook + "/dead_letters"},
+		{OperationID: "post-webhooks-webhook_id-dead_letters-delivery_id-redeliver", Path: hook + "/dead_letters/unknown/redeliver", Status: http.StatusNotFound},
+		{Name: "delete-webhooks-webhook_id without if-match", OperationID: "delete-webhooks-webhook_id", Path: hook, Status: http.StatusPreconditionRequired},
+		{OperationID: "delete-webhooks-webhook_id", Path: hook, Header: anyVersion, Status: http.StatusNoContent},
+		{Name: "delete-webhooks-webhook_id again", OperationID: "delete-webhooks-webhook_id", Path: hook, Header: anyVersion, Status: http.StatusNotFound},
+	}
+}
+
+// scheduleCases exercise the schedule operations, against an existing schedule with the given id
+func scheduleCases(id string) []contract.Case {
+	sched := "/schedules/" + id
+	body := `{"name":"ITB displays off","cron":"0 23 * * *","time_zone":"America/Denver","targets":{"building_abbreviations":["ITB"]},"action":{"av_display_state":{"av_display_powered":false}}}`
+
+	return []contract.Case{
+		{OperationID: "get-schedules", Path: "/schedules"},
+		{OperationID: "post-schedules", Path: "/schedules", Body: body, Status: http.StatusCreated},
+		{Name: "post-schedules bad cron", OperationID: "post-schedules", Path: "/schedules", Body: `{"cron":"0 25 * * *","targets":{"av_room_ids":["ITB-1101"]},"action":{"av_audio_output_state":{"av_audio_output_muted":true}}}`, Status: http.StatusBadRequest},
+		{Name: "post-schedules bad time zone", OperationID: "post-schedules", Path: "/schedules", Body: `{"cron":"0 23 * * *","time_zone":"Mars/Olympus","targets":{"av_room_ids":["ITB-1101"]},"action":{"av_audio_output_state":{"av_audio_output_muted":true}}}`, Status: http.StatusBadRequest},
+		{Name: "post-schedules no targets", OperationID: "post-schedules", Path: "/schedules", Body: `{"cron":"0 23 * * *","targets":{},"action":{"av_audio_output_state":{"av_audio_output_muted":true}}}`, Status: http.StatusBadRequest},
+		{Name: "post-schedules no action", OperationID: "post-schedules", Path: "/schedules", Body: `{"cron":"0 23 * * *","targets":{"av_room_ids":["ITB-1101"]},"action":{}}`, Status: http.StatusBadRequest},
+		{OperationID: "get-schedules-schedule_id", Path: sched},
+		{Name: "get-schedules-schedule_id unknown", OperationID: "get-schedules-schedule_id", Path: "/schedules/unknown", Status: http.StatusNotFound},
+		{OperationID: "get-schedules-schedule_id-runs", Path: sched + "/runs"},
+		{Name: "get-schedules-schedule_id-runs room", OperationID: "get-schedules-schedule_id-runs", Path: sched + "/runs?av_room_id=ITB-1101"},
+		{OperationID: "put-schedules-schedule_id", Path: sched, Body: body, Header: anyVersion},
+		{Name: "put-schedules-schedule_id without if-match", OperationID: "put-schedules-schedule_id", Path: sched, Body: body, Status: http.StatusPreconditionRequired},
+		{Name: "put-schedules-schedule_id stale", OperationID: "put-schedules-schedule_id", Path: sched, Body: body, Header: map[string]string{"If-Match": `"stale"`}, Status: http.StatusPreconditionFailed},
+		{OperationID: "delete-schedules-schedule_id", Path: sched, Header: anyVersion, Status: http.StatusNoContent},
+		{Name: "delete-schedules-schedule_id again", OperationID: "delete-schedules-schedule_id", Path: sched, Header: anyVersion, Status: http.StatusNotFound},
+	}
+}
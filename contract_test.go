@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/contract"
+	"github.com/byuoitav/uapi-translator/history"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/simulator"
+	"go.uber.org/zap"
+)
+
+// testServer builds the translator the same way main does, against simulated
+// Couch and AV API servers and an OPA that gives allow as every decision
+func testServer(t *testing.T, allow bool) (*server, *simulator.AVAPI) {
+	t.Helper()
+
+	if !testing.Verbose() {
+		log.Config.Level.SetLevel(zap.FatalLevel)
+	}
+
+	couch := simulator.NewCouch()
+	avapi := simulator.NewAVAPI()
+	if err := simulator.Load(simulator.DefaultFixtures(), couch, avapi); err != nil {
+		t.Fatalf("unable to load fixtures: %s", err)
+	}
+
+	couchServer := httptest.NewServer(couch)
+	t.Cleanup(func() {
+		// webhooks long poll couch's _changes feeds, which don't end on their own
+		couchServer.CloseClientConnections()
+		couchServer.Close()
+	})
+
+	avapiServer := httptest.NewServer(avapi)
+	t.Cleanup(avapiServer.Close)
+
+	opaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]bool{"allow": allow},
+		})
+	}))
+	t.Cleanup(opaServer.Close)
+
+	// the services still read these from the environment
+	t.Setenv("DB_ADDRESS", couchServer.URL)
+	t.Setenv("AV_API_URL", avapiServer.URL)
+
+	srv, err := newServer(config{
+		OPAURL:             opaServer.URL,
+		DBAddress:          couchServer.URL,
+		EventPollInterval:  100 * time.Millisecond,
+		WebhookMaxAttempts: 1,
+		HistoryRooms:       []string{"ITB-1101"},
+		HealthBuildings:    []string{"ITB"},
+		HealthInterval:     time.Hour,
+		HealthThreshold:    5 * time.Minute,
+		HealthTimeout:      10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unable to build server: %s", err)
+	}
+
+	srv.router.Logger.SetOutput(ioutil.Discard)
+
+	return srv, avapi
+}
+
+// TestContract checks that every operation in av-domain.v1.yaml returns
+// responses matching its declared schemas
+func TestContract(t *testing.T) {
+	srv, avapi := testServer(t, true)
+
+	spec, err := openapi.Parse(specDoc)
+	if err != nil {
+		t.Fatalf("unable to parse spec: %s", err)
+	}
+
+	// one of ITB's devices is unreachable when the monitor first checks
+	avapi.SetUnreachable("ITB-1101-D2", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	srv.start(ctx)
+	srv.monitor.Check()
+
+	cases := append([]contract.Case{}, contractCases...)
+
+	hook, err := srv.hooks.Create(models.WebhookInput{
+		URL:     "http://localhost:9/hook",
+		Filters: models.WebhookFilters{BldgAbbrs: []string{"ITB"}},
+	})
+	if err != nil {
+		t.Fatalf("unable to create webhook: %s", err)
+	}
+
+	cases = append(cases, webhookCases(hook.WebhookID)...)
+
+	sched, err := srv.scheds.Create(models.ScheduleInput{
+		Cron:    "0 23 * * *",
+		Targets: models.ScheduleTargets{BldgAbbrs: []string{"ITB"}},
+		Action:  models.ScheduleAction{Display: &models.DisplayStateUpdate{Powered: new(bool)}},
+	})
+	if err != nil {
+		t.Fatalf("unable to create schedule: %s", err)
+	}
+
+	cases = append(cases, scheduleCases(sched.ScheduleID)...)
+
+	// a day of recorded state, so history cases have something to return
+	start := time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i, powered := range []bool{true, false} {
+		srv.recorder.Store.Add(history.Record{
+			Time:       start.Add(time.Duration(i) * 10 * time.Hour),
+			RoomID:     "ITB-1101",
+			ResourceID: "ITB-1101-Display1",
+			Display:    &models.DisplayState{Powered: powered, Input: "ITB-1101-HDMI1"},
+		})
+	}
+	srv.recorder.Store.Add(history.Record{
+		Time:        start,
+		RoomID:      "ITB-1101",
+		ResourceID:  "ITB-1101-MasterAudio1",
+		AudioOutput: &models.AudioOutputState{Volume: 30},
+	})
+
+	for _, id := range contract.Uncovered(spec, cases) {
+		t.Errorf("no case exercises %s", id)
+	}
+
+	for _, c := range cases {
+		name := c.Name
+		if name == "" {
+			name = c.OperationID
+		}
+
+		c := c
+		t.Run(name, func(t *testing.T) {
+			for _, res := range contract.Run(spec, srv.router, []contract.Case{c}) {
+				if !res.Passed() {
+					t.Error(res)
+				}
+			}
+		})
+	}
+}
+
+// TestAuthorization checks that OPA guards the API but not the server's own routes
+func TestAuthorization(t *testing.T) {
+	srv, _ := testServer(t, false)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/rooms", http.StatusForbidden},
+		{"/rooms/ITB-1101", http.StatusForbidden},
+		{"/webhooks", http.StatusForbidden},
+		{"/healthz", http.StatusOK},
+		{"/openapi.yaml", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("GET %s: got status %d, want %d", tt.path, rec.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const _buildingsPath = "buildings"
+
+// Buildings
+type BuildingResponse struct {
+	Docs     []Building `json:"docs"`
+	Bookmark string     `json:"bookmark"`
+	Warning  string     `json:"warning"`
+}
+
+type Building struct {
+	Rev         string `json:"_rev,omitempty"`
+	ID          string `json:"_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GetBuildingByID returns the building document for the given abbreviation
+func (s *Service) GetBuildingByID(bldg string) (*Building, error) {
+	path := fmt.Sprintf("%s/%s", _buildingsPath, url.PathEscape(bldg))
+
+	b := Building{}
+	err := s.makeRequest("GET", path, nil, &b)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetBuildingByID make request: %w", err)
+	}
+
+	return &b, nil
+}
+
+// GetBuildings returns every building document. Not every building with
+// rooms has one, and an empty list is returned if the database doesn't exist.
+func (s *Service) GetBuildings() ([]Building, error) {
+	path := fmt.Sprintf("%s/_find", _buildingsPath)
+	r := BuildingResponse{}
+
+	// Format query
+	q := Query{
+		Selector: Selector{}.GT("_id", "\x00"),
+		Limit:    10000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetBuildings query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return []Building{}, nil
+	case err != nil:
+		return nil, fmt.Errorf("db/GetBuildings couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
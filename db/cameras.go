@@ -0,0 +1,80 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// CameraConfig is the camera configuration in a ui-configuration document.
+// structs.Preset doesn't have cameras, so only the presets' cameras are read.
+type CameraConfig struct {
+	ID      string `json:"_id"`
+	Presets []struct {
+		Name    string   `json:"name"`
+		Cameras []Camera `json:"cameras"`
+	} `json:"presets"`
+}
+
+// Camera is a camera in a preset, along with the URLs that control it
+type Camera struct {
+	DisplayName string         `json:"displayName"`
+	TiltUp      string         `json:"tiltUp"`
+	TiltDown    string         `json:"tiltDown"`
+	PanLeft     string         `json:"panLeft"`
+	PanRight    string         `json:"panRight"`
+	PanTiltStop string         `json:"panTiltStop"`
+	ZoomIn      string         `json:"zoomIn"`
+	ZoomOut     string         `json:"zoomOut"`
+	ZoomStop    string         `json:"zoomStop"`
+	Stream      string         `json:"stream"`
+	Presets     []CameraPreset `json:"presets"`
+}
+
+// CameraPreset is a named position a camera can be moved to by requesting SetPreset
+type CameraPreset struct {
+	DisplayName string `json:"displayName"`
+	SetPreset   string `json:"setPreset"`
+}
+
+type cameraConfigResponse struct {
+	Docs []CameraConfig `json:"docs"`
+}
+
+// GetCameraConfig returns the camera configuration in the room's ui-configuration document
+func (s *Service) GetCameraConfig(roomID string) (*CameraConfig, error) {
+	path := fmt.Sprintf("%s/%s", _uiConfigPath, url.PathEscape(roomID))
+
+	config := CameraConfig{}
+	err := s.makeRequest("GET", path, nil, &config)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetCameraConfig make request: %w", err)
+	}
+
+	return &config, nil
+}
+
+// GetCameraConfigs returns the camera configuration of every room matching sel that has cameras
+func (s *Service) GetCameraConfigs(sel Selector) ([]CameraConfig, error) {
+	path := fmt.Sprintf("%s/_find", _uiConfigPath)
+	r := cameraConfigResponse{}
+
+	// Format query
+	q := Query{
+		Selector: sel.ElemMatch("presets", Selector{}.Exists("cameras")),
+		Fields:   []string{"_id", "presets"},
+		Limit:    10000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetCameraConfigs query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetCameraConfigs couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
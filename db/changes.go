@@ -0,0 +1,77 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Database names that can be watched for changes
+const (
+	RoomsDB       = _roomsPath
+	DevicesDB     = _devicesPath
+	UIConfigDB    = _uiConfigPath
+	DeviceTypesDB = _deviceTypesPath
+	BuildingsDB   = _buildingsPath
+	ScenesDB      = _scenesPath
+)
+
+type ChangesResponse struct {
+	Results []Change        `json:"results"`
+	LastSeq json.RawMessage `json:"last_seq"`
+}
+
+type Change struct {
+	Seq     json.RawMessage `json:"seq"`
+	ID      string          `json:"id"`
+	Deleted bool            `json:"deleted"`
+	Changes []struct {
+		Rev string `json:"rev"`
+	} `json:"changes"`
+}
+
+// Rev returns the revision the document was changed to
+func (c Change) Rev() string {
+	if len(c.Changes) == 0 {
+		return ""
+	}
+
+	return c.Changes[0].Rev
+}
+
+// GetChanges long polls the database's _changes feed for changes after since,
+// returning once there is at least one or timeout passes. since may be "now".
+// The returned sequence should be passed as since in the next call.
+func (s *Service) GetChanges(database, since string, timeout time.Duration) ([]Change, string, error) {
+	q := url.Values{}
+	q.Set("feed", "longpoll")
+	q.Set("since", since)
+	q.Set("timeout", fmt.Sprintf("%d", timeout.Milliseconds()))
+
+	path := fmt.Sprintf("%s/_changes?%s", database, q.Encode())
+
+	var r ChangesResponse
+	err := s.makeRequest("GET", path, nil, &r)
+	if err != nil {
+		return nil, since, fmt.Errorf("db/GetChanges couch request: %w", err)
+	}
+
+	last := seqString(r.LastSeq)
+	if last == "" {
+		last = since
+	}
+
+	return r.Results, last, nil
+}
+
+// seqString returns a sequence as a string. Couch 1.x uses numbers, later versions use strings.
+func seqString(seq json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(seq, &s); err == nil {
+		return s
+	}
+
+	return strings.TrimSpace(string(seq))
+}
@@ -17,6 +17,9 @@ import (
 // to fulfill a request
 var ErrNotFound = errors.New("The requested document was not found")
 
+// ErrConflict is returned when a document is saved with a revision that is no longer current
+var ErrConflict = errors.New("Document update conflict")
+
 // Service represents a database service and the config necessary to run the service
 type Service struct {
 	Address  string
@@ -24,19 +27,6 @@ type Service struct {
 	Password string
 }
 
-// search represents a search parameter in a couch _find call
-type search struct {
-	GT    string `json:"$gt,omitempty"`
-	LT    string `json:"$lt,omitempty"`
-	Regex string `json:"$regex,omitempty"`
-}
-
-// query represents a query body to be sent to couch
-type query struct {
-	Selector map[string]interface{} `json:"selector"`
-	Limit    int                    `json:"limit"`
-}
-
 func DBSearch(url, method string, query, resp interface{}) error {
 	var body []byte
 	var err error
@@ -89,8 +79,13 @@ func (s *Service) makeRequest(method, path string, body []byte, responseBody int
 		return ErrNotFound
 	}
 
-	// Check for non 200
-	if res.StatusCode != 200 {
+	// Check for a stale revision
+	if res.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+
+	// Check for non 2xx (saving a document returns 201)
+	if res.StatusCode/100 != 2 {
 		return fmt.Errorf("db/makeRequest Error response from couch. Code: %d", res.StatusCode)
 	}
 
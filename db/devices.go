@@ -3,6 +3,8 @@ package db
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 )
 
 const _devicesPath = "devices"
@@ -15,19 +17,65 @@ type DeviceResponse struct {
 }
 
 type Device struct {
-	ID     string            `json:"_id"`
-	TypeID string            `json:"typeID"`
-	Tags   map[string]string `json:"tags"`
+	ID          string            `json:"_id"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name"`
+	Type        DeviceTypeRef     `json:"type"`
+	TypeID      string            `json:"typeID"`
+	Roles       []DeviceTypeRole  `json:"roles"`
+	Tags        map[string]string `json:"tags"`
+}
+
+// HasRole reports whether the device has the role
+func (d Device) HasRole(role string) bool {
+	for _, r := range d.Roles {
+		if r.ID == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeviceTypeRef is the device type a device document points to
+type DeviceTypeRef struct {
+	ID string `json:"_id"`
 }
 
 type DeviceType struct {
-	ID   string            `json:"_id"`
-	Tags map[string]string `json:"tags"`
+	ID          string              `json:"_id"`
+	Description string              `json:"description"`
+	Tags        map[string]string   `json:"tags"`
+	Roles       []DeviceTypeRole    `json:"roles"`
+	Ports       []DeviceTypePort    `json:"ports"`
+	Commands    []DeviceTypeCommand `json:"commands"`
+}
+
+type DeviceTypeResponse struct {
+	Docs     []DeviceType `json:"docs"`
+	Bookmark string       `json:"bookmark"`
+	Warning  string       `json:"warning"`
+}
+
+type DeviceTypeRole struct {
+	ID string `json:"_id"`
+}
+
+type DeviceTypePort struct {
+	ID           string `json:"_id"`
+	FriendlyName string `json:"friendly_name"`
+	PortType     string `json:"port_type"`
+	Description  string `json:"description"`
+}
+
+type DeviceTypeCommand struct {
+	ID          string `json:"_id"`
+	Description string `json:"description"`
 }
 
 // GetDevicebyID gets a device document from couch given the id
 func (s *Service) GetDeviceByID(deviceID string) (*Device, error) {
-	path := fmt.Sprintf("%s/%s", _devicesPath, deviceID)
+	path := fmt.Sprintf("%s/%s", _devicesPath, url.PathEscape(deviceID))
 	d := Device{}
 
 	// Make request
@@ -45,14 +93,16 @@ func (s *Service) GetDevicesByRoom(roomID string) ([]Device, error) {
 	path := fmt.Sprintf("%s/_find", _devicesPath)
 	r := DeviceResponse{}
 
+	// room ids are {BLDG}-{Room}
+	parts := strings.Split(roomID, "-")
+	if len(parts) != 2 {
+		return []Device{}, nil
+	}
+
 	// Format query
-	q := query{
-		Selector: map[string]interface{}{
-			"_id": search{
-				Regex: fmt.Sprintf("%s-", roomID),
-			},
-		},
-		Limit: 1000,
+	q := Query{
+		Selector: Selector{}.Segments("_id", parts[0], parts[1], ""),
+		Limit:    1000,
 	}
 	body, err := json.Marshal(&q)
 	if err != nil {
@@ -68,9 +118,81 @@ func (s *Service) GetDevicesByRoom(roomID string) ([]Device, error) {
 	return r.Docs, nil
 }
 
+// GetDevicesByBuilding returns every device in the building
+func (s *Service) GetDevicesByBuilding(bldg string) ([]Device, error) {
+	path := fmt.Sprintf("%s/_find", _devicesPath)
+	r := DeviceResponse{}
+
+	// Format query
+	q := Query{
+		Selector: Selector{}.Segments("_id", bldg, "", ""),
+		Limit:    100000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDevicesByBuilding query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDevicesByBuilding couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
+
+// GetAllDevices returns every device
+func (s *Service) GetAllDevices() ([]Device, error) {
+	path := fmt.Sprintf("%s/_find", _devicesPath)
+	r := DeviceResponse{}
+
+	// Format query
+	q := Query{
+		Selector: Selector{}.GT("_id", "\x00"),
+		Limit:    100000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetAllDevices query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetAllDevices couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
+
+// GetDevicesWithRole returns the devices that have the role and match sel
+func (s *Service) GetDevicesWithRole(role string, sel Selector) ([]Device, error) {
+	path := fmt.Sprintf("%s/_find", _devicesPath)
+	r := DeviceResponse{}
+
+	// Format query
+	q := Query{
+		Selector: sel.ElemMatch("roles", Selector{}.Eq("_id", role)),
+		Limit:    100000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDevicesWithRole query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDevicesWithRole couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
+
 // GetDeviceTypeByID returns the device type document for the given id
 func (s *Service) GetDeviceTypeByID(deviceTypeID string) (*DeviceType, error) {
-	path := fmt.Sprintf("%s/%s", _deviceTypesPath, deviceTypeID)
+	path := fmt.Sprintf("%s/%s", _deviceTypesPath, url.PathEscape(deviceTypeID))
 	d := DeviceType{}
 
 	// Make request
@@ -81,3 +203,71 @@ func (s *Service) GetDeviceTypeByID(deviceTypeID string) (*DeviceType, error) {
 
 	return &d, nil
 }
+
+// GetDeviceTypes returns every device type document
+func (s *Service) GetDeviceTypes() ([]DeviceType, error) {
+	path := fmt.Sprintf("%s/_find", _deviceTypesPath)
+	r := DeviceTypeResponse{}
+
+	// Format query
+	q := Query{
+		Selector: Selector{}.GT("_id", "\x00"),
+		Limit:    10000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDeviceTypes query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDeviceTypes couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
+
+// GetDeviceTypeCounts returns how many devices there are of each device type
+func (s *Service) GetDeviceTypeCounts() (map[string]int, error) {
+	path := fmt.Sprintf("%s/_find", _devicesPath)
+
+	var r struct {
+		Docs []struct {
+			TypeID string `json:"typeID"`
+			Type   struct {
+				ID string `json:"_id"`
+			} `json:"type"`
+		} `json:"docs"`
+	}
+
+	// Format query
+	q := Query{
+		Selector: Selector{}.GT("_id", "\x00"),
+		Fields:   []string{"typeID", "type"},
+		Limit:    100000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDeviceTypeCounts query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetDeviceTypeCounts couch request: %w", err)
+	}
+
+	counts := map[string]int{}
+	for _, d := range r.Docs {
+		// devices have their type in type._id, or typeID in older documents
+		id := d.Type.ID
+		if id == "" {
+			id = d.TypeID
+		}
+
+		counts[id]++
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+const _scenesPath = "scenes"
+
+type putResponse struct {
+	Rev string `json:"rev"`
+}
+
+// GetDoc reads the document with the id in the database into doc
+func (s *Service) GetDoc(database, id string, doc interface{}) error {
+	path := fmt.Sprintf("%s/%s", database, url.PathEscape(id))
+
+	if err := s.makeRequest("GET", path, nil, doc); err != nil {
+		return fmt.Errorf("db/GetDoc %s/%s: %w", database, id, err)
+	}
+
+	return nil
+}
+
+// PutDoc saves doc as the document with the id in the database, returning its
+// new revision. doc's _rev must be the document's current revision, or empty
+// if it is new, otherwise ErrConflict is returned.
+func (s *Service) PutDoc(database, id string, doc interface{}) (string, error) {
+	path := fmt.Sprintf("%s/%s", database, url.PathEscape(id))
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("db/PutDoc marshal: %w", err)
+	}
+
+	var res putResponse
+	if err := s.makeRequest("PUT", path, body, &res); err != nil {
+		return "", fmt.Errorf("db/PutDoc %s/%s: %w", database, id, err)
+	}
+
+	return res.Rev, nil
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Query is the body of a couch _find request
+type Query struct {
+	Selector Selector `json:"selector"`
+	Fields   []string `json:"fields,omitempty"`
+	Limit    int      `json:"limit"`
+}
+
+// Selector is a couch mango selector. Values given to its methods are
+// escaped, so they are matched literally and can come straight from a request.
+type Selector map[string]interface{}
+
+// Eq matches documents whose field is exactly value
+func (s Selector) Eq(field, value string) Selector {
+	return s.op(field, "$eq", value)
+}
+
+// In matches documents whose field is exactly one of values
+func (s Selector) In(field string, values ...string) Selector {
+	if values == nil {
+		values = []string{}
+	}
+
+	return s.op(field, "$in", values)
+}
+
+// Prefix matches documents whose field starts with prefix
+func (s Selector) Prefix(field, prefix string) Selector {
+	return s.op(field, "$regex", "^"+regexp.QuoteMeta(prefix))
+}
+
+// GT matches documents whose field sorts after value
+func (s Selector) GT(field, value string) Selector {
+	return s.op(field, "$gt", value)
+}
+
+// Segments matches documents whose field is made up of len(values) dash
+// separated segments, like a {BLDG}-{Room}-{Device} id. Each segment must
+// equal the value in the same position, unless that value is empty.
+// A value that contains a dash can't be a segment, so it matches nothing.
+func (s Selector) Segments(field string, values ...string) Selector {
+	options := make([][]string, len(values))
+	for i, v := range values {
+		if v != "" {
+			options[i] = []string{v}
+		}
+	}
+
+	return s.AnySegments(field, options...)
+}
+
+// AnySegments is like Segments, except each segment may equal any of the
+// values in the same position. A position without values matches any segment.
+func (s Selector) AnySegments(field string, values ...[]string) Selector {
+	exact := true
+	segments := make([]string, len(values))
+	patterns := make([]string, len(values))
+	for i, options := range values {
+		if len(options) == 0 {
+			exact = false
+			patterns[i] = "[^-]+"
+			continue
+		}
+
+		var valid []string
+		for _, v := range options {
+			if v != "" && !strings.Contains(v, "-") {
+				valid = append(valid, v)
+			}
+		}
+
+		switch len(valid) {
+		case 0:
+			return s.In(field)
+		case 1:
+			segments[i] = valid[0]
+			patterns[i] = regexp.QuoteMeta(valid[0])
+		default:
+			exact = false
+			for j := range valid {
+				valid[j] = regexp.QuoteMeta(valid[j])
+			}
+			patterns[i] = "(?:" + strings.Join(valid, "|") + ")"
+		}
+	}
+
+	if exact {
+		return s.Eq(field, strings.Join(segments, "-"))
+	}
+
+	return s.op(field, "$regex", "^"+strings.Join(patterns, "-")+"$")
+}
+
+// Exists matches documents that have field
+func (s Selector) Exists(field string) Selector {
+	return s.op(field, "$exists", true)
+}
+
+// ElemMatch matches documents whose field is an array with an element matching sel
+func (s Selector) ElemMatch(field string, sel Selector) Selector {
+	return s.op(field, "$elemMatch", sel)
+}
+
+// Search matches documents where any of fields contains text, ignoring case
+func (s Selector) Search(text string, fields ...string) Selector {
+	var or []Selector
+	for _, f := range fields {
+		or = append(or, Selector{}.op(f, "$regex", "(?i)"+regexp.QuoteMeta(text)))
+	}
+
+	s["$or"] = or
+	return s
+}
+
+// Field joins the names of nested fields into a field name, escaping any dots in them
+func Field(names ...string) string {
+	escaped := make([]string, len(names))
+	for i := range names {
+		escaped[i] = strings.ReplaceAll(names[i], ".", `\.`)
+	}
+
+	return strings.Join(escaped, ".")
+}
+
+// op adds a condition on field, keeping any others already on it
+func (s Selector) op(field, op string, value interface{}) Selector {
+	cond, ok := s[field].(map[string]interface{})
+	if !ok {
+		cond = map[string]interface{}{}
+		s[field] = cond
+	}
+
+	cond[op] = value
+	return s
+}
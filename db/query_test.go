@@ -0,0 +1,114 @@
+package db
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestSelectorEscapesInput(t *testing.T) {
+	tests := []struct {
+		name string
+		sel  Selector
+
+		// json is the selector couch is sent
+		json string
+
+		// matches and misses are values of the field the selector's regex should and shouldn't match
+		matches, misses []string
+	}{
+		{
+			name: "eq",
+			sel:  Selector{}.Eq("_id", "ITB-.*"),
+			json: `{"_id":{"$eq":"ITB-.*"}}`,
+		},
+		{
+			name:    "prefix",
+			sel:     Selector{}.Prefix("_id", ".*"),
+			json:    `{"_id":{"$regex":"^\\.\\*"}}`,
+			matches: []string{".*-1101"},
+			misses:  []string{"ITB-1101"},
+		},
+		{
+			name:    "prefix anchors",
+			sel:     Selector{}.Prefix("_id", "^ITB$"),
+			json:    `{"_id":{"$regex":"^\\^ITB\\$"}}`,
+			matches: []string{"^ITB$-1101"},
+			misses:  []string{"ITB", "ITB-1101"},
+		},
+		{
+			name: "segments with one value each",
+			sel:  Selector{}.Segments("_id", "(ITB", "1101|"),
+			json: `{"_id":{"$eq":"(ITB-1101|"}}`,
+		},
+		{
+			name:    "segments with a wildcard",
+			sel:     Selector{}.Segments("_id", "IT.", ""),
+			json:    `{"_id":{"$regex":"^IT\\.-[^-]+$"}}`,
+			matches: []string{"IT.-1101"},
+			misses:  []string{"ITB-1101"},
+		},
+		{
+			name:    "any segments",
+			sel:     Selector{}.AnySegments("_id", []string{"ITB|JKB", "(.*)"}, nil),
+			json:    `{"_id":{"$regex":"^(?:ITB\\|JKB|\\(\\.\\*\\))-[^-]+$"}}`,
+			matches: []string{"ITB|JKB-1101", "(.*)-1101"},
+			misses:  []string{"ITB-1101", "JKB-1101", "X-1101"},
+		},
+		{
+			name:    "search",
+			sel:     Selector{}.Search("a|b(", "name"),
+			json:    `{"$or":[{"name":{"$regex":"(?i)a\\|b\\("}}]}`,
+			matches: []string{"Room A|B( west"},
+			misses:  []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.sel)
+			if err != nil {
+				t.Fatalf("unable to marshal selector: %s", err)
+			}
+
+			if string(b) != tt.json {
+				t.Errorf("got selector %s, want %s", b, tt.json)
+			}
+
+			if len(tt.matches) == 0 && len(tt.misses) == 0 {
+				return
+			}
+
+			re := regexp.MustCompile(selectorRegex(t, tt.sel))
+			for _, v := range tt.matches {
+				if !re.MatchString(v) {
+					t.Errorf("%s doesn't match %q", re, v)
+				}
+			}
+
+			for _, v := range tt.misses {
+				if re.MatchString(v) {
+					t.Errorf("%s matches %q", re, v)
+				}
+			}
+		})
+	}
+}
+
+// selectorRegex returns the only $regex in sel
+func selectorRegex(t *testing.T, sel Selector) string {
+	t.Helper()
+
+	for field, v := range sel {
+		if field == "$or" {
+			return selectorRegex(t, v.([]Selector)[0])
+		}
+
+		if re, ok := v.(map[string]interface{})["$regex"].(string); ok {
+			return re
+		}
+	}
+
+	t.Fatalf("selector has no $regex: %v", sel)
+	return ""
+}
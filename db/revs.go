@@ -0,0 +1,75 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+type allDocsResponse struct {
+	Rows []struct {
+		ID    string `json:"id"`
+		Value struct {
+			Rev string `json:"rev"`
+		} `json:"value"`
+	} `json:"rows"`
+}
+
+type dbInfoResponse struct {
+	UpdateSeq json.RawMessage `json:"update_seq"`
+}
+
+// GetUpdateSeq returns the database's update sequence, which changes whenever
+// any document in it does. A database that doesn't exist has an empty sequence.
+func (s *Service) GetUpdateSeq(database string) (string, error) {
+	var r dbInfoResponse
+	err := s.makeRequest("GET", database, nil, &r)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("db/GetUpdateSeq couch request: %w", err)
+	}
+
+	return seqString(r.UpdateSeq), nil
+}
+
+// GetRevs returns the current _rev of every document in the database whose
+// id starts with prefix, by id. An empty prefix returns every document's.
+// A database that doesn't exist has no documents.
+func (s *Service) GetRevs(database, prefix string) (map[string]string, error) {
+	q := url.Values{}
+	if prefix != "" {
+		start, err := json.Marshal(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("db/GetRevs marshal startkey: %w", err)
+		}
+
+		end, err := json.Marshal(prefix + "\ufff0")
+		if err != nil {
+			return nil, fmt.Errorf("db/GetRevs marshal endkey: %w", err)
+		}
+
+		q.Set("startkey", string(start))
+		q.Set("endkey", string(end))
+	}
+
+	path := fmt.Sprintf("%s/_all_docs?%s", database, q.Encode())
+
+	var r allDocsResponse
+	err := s.makeRequest("GET", path, nil, &r)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return map[string]string{}, nil
+	case err != nil:
+		return nil, fmt.Errorf("db/GetRevs couch request: %w", err)
+	}
+
+	revs := make(map[string]string, len(r.Rows))
+	for _, row := range r.Rows {
+		revs[row.ID] = row.Value.Rev
+	}
+
+	return revs, nil
+}
@@ -1,7 +1,9 @@
 package db
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 )
 
 const _roomsPath = "rooms"
@@ -21,7 +23,7 @@ type Room struct {
 
 // GetRoomByID returns the Room document for the given roomID
 func (s *Service) GetRoomByID(roomID string) (*Room, error) {
-	path := fmt.Sprintf("%s/%s", _roomsPath, roomID)
+	path := fmt.Sprintf("%s/%s", _roomsPath, url.PathEscape(roomID))
 
 	room := Room{}
 	err := s.makeRequest("GET", path, nil, &room)
@@ -32,3 +34,47 @@ func (s *Service) GetRoomByID(roomID string) (*Room, error) {
 
 	return &room, nil
 }
+
+// GetRoomsByBuilding returns every room document in the building
+func (s *Service) GetRoomsByBuilding(bldg string) ([]Room, error) {
+	rooms, err := s.findRooms(Selector{}.Segments("_id", bldg, ""))
+	if err != nil {
+		return nil, fmt.Errorf("db/GetRoomsByBuilding: %w", err)
+	}
+
+	return rooms, nil
+}
+
+// GetAllRooms returns every room document
+func (s *Service) GetAllRooms() ([]Room, error) {
+	rooms, err := s.findRooms(Selector{}.GT("_id", "\x00"))
+	if err != nil {
+		return nil, fmt.Errorf("db/GetAllRooms: %w", err)
+	}
+
+	return rooms, nil
+}
+
+// findRooms returns the room documents matching the selector, in id order
+func (s *Service) findRooms(sel Selector) ([]Room, error) {
+	path := fmt.Sprintf("%s/_find", _roomsPath)
+	r := RoomResponse{}
+
+	// Format query
+	q := Query{
+		Selector: sel,
+		Limit:    10000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
@@ -1,23 +1,61 @@
 package db
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/byuoitav/uapi-translator/log"
 	"go.uber.org/zap"
 )
 
+// stateClient makes the requests to the AV API and the devices it points at.
+// Changing a display's power can take a while, so its timeout is generous.
+var stateClient = &http.Client{Timeout: 30 * time.Second}
+
 func GetState(url, method string, responseBody interface{}) error {
-	req, err := http.NewRequest(method, url, nil)
+	return SetStateContext(context.Background(), url, method, nil, responseBody)
+}
+
+// GetStateContext is GetState, giving up when ctx is done
+func GetStateContext(ctx context.Context, url, method string, responseBody interface{}) error {
+	return SetStateContext(ctx, url, method, nil, responseBody)
+}
+
+// SetState makes a request to the AV API with state as the JSON body
+func SetState(url, method string, state, responseBody interface{}) error {
+	return SetStateContext(context.Background(), url, method, state, responseBody)
+}
+
+// SetStateContext is SetState, giving up when ctx is done
+func SetStateContext(ctx context.Context, url, method string, state, responseBody interface{}) error {
+	var body io.Reader
+	if state != nil {
+		b, err := json.Marshal(state)
+		if err != nil {
+			log.Log.Error("failed to marshal state", zap.String("url", url), zap.Error(err))
+			return err
+		}
+
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		log.Log.Error("failed to create new http request", zap.String("url", url), zap.Error(err))
 		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := stateClient.Do(req)
 	if err != nil {
 		log.Log.Error("failed to make http request", zap.String("url", url), zap.Error(err))
 		return err
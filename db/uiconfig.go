@@ -0,0 +1,119 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/byuoitav/common/structs"
+)
+
+const _uiConfigPath = "ui-configuration"
+
+type UIConfigResponse struct {
+	Docs     []UIConfig `json:"docs"`
+	Bookmark string     `json:"bookmark"`
+	Warning  string     `json:"warning"`
+}
+
+type UIConfig struct {
+	Rev string `json:"_rev,omitempty"`
+	structs.UIConfig
+}
+
+// GetUIConfig returns the ui-configuration document for the given roomID
+func (s *Service) GetUIConfig(roomID string) (*UIConfig, error) {
+	path := fmt.Sprintf("%s/%s", _uiConfigPath, url.PathEscape(roomID))
+
+	config := UIConfig{}
+	err := s.makeRequest("GET", path, nil, &config)
+	if err != nil {
+		return nil, fmt.Errorf("db/GetUIConfig make request: %w", err)
+	}
+
+	return &config, nil
+}
+
+// GetRoomIDsByBuilding returns the ids of every room in the building that has a ui-configuration
+func (s *Service) GetRoomIDsByBuilding(bldg string) ([]string, error) {
+	ids, err := s.findRoomIDs(Selector{}.Segments("_id", bldg, ""))
+	if err != nil {
+		return nil, fmt.Errorf("db/GetRoomIDsByBuilding: %w", err)
+	}
+
+	return ids, nil
+}
+
+// findRoomIDs returns the ids of the ui-configurations matching the selector
+func (s *Service) findRoomIDs(sel Selector) ([]string, error) {
+	path := fmt.Sprintf("%s/_find", _uiConfigPath)
+	r := UIConfigResponse{}
+
+	// Format query
+	q := Query{
+		Selector: sel,
+		Fields:   []string{"_id"},
+		Limit:    10000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("couch request: %w", err)
+	}
+
+	ids := make([]string, len(r.Docs))
+	for i := range r.Docs {
+		ids[i] = r.Docs[i].ID
+	}
+
+	return ids, nil
+}
+
+// GetUIConfigsByBuilding returns every ui-configuration document in the building
+func (s *Service) GetUIConfigsByBuilding(bldg string) ([]UIConfig, error) {
+	configs, err := s.findUIConfigs(Selector{}.Segments("_id", bldg, ""))
+	if err != nil {
+		return nil, fmt.Errorf("db/GetUIConfigsByBuilding: %w", err)
+	}
+
+	return configs, nil
+}
+
+// GetAllUIConfigs returns every ui-configuration document
+func (s *Service) GetAllUIConfigs() ([]UIConfig, error) {
+	configs, err := s.findUIConfigs(Selector{}.GT("_id", "\x00"))
+	if err != nil {
+		return nil, fmt.Errorf("db/GetAllUIConfigs: %w", err)
+	}
+
+	return configs, nil
+}
+
+// findUIConfigs returns the ui-configuration documents matching the selector
+func (s *Service) findUIConfigs(sel Selector) ([]UIConfig, error) {
+	path := fmt.Sprintf("%s/_find", _uiConfigPath)
+	r := UIConfigResponse{}
+
+	// Format query
+	q := Query{
+		Selector: sel,
+		Limit:    10000,
+	}
+	body, err := json.Marshal(&q)
+	if err != nil {
+		return nil, fmt.Errorf("query marshal: %w", err)
+	}
+
+	// Make the request
+	err = s.makeRequest("POST", path, body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("couch request: %w", err)
+	}
+
+	return r.Docs, nil
+}
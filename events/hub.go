@@ -0,0 +1,293 @@
+// Package events produces a stream of display and audio output state changes
+// by polling the AV API for each room that has a subscriber and diffing the results.
+package events
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"go.uber.org/zap"
+)
+
+const (
+	TypeDisplay     = "display"
+	TypeAudioOutput = "audio_output"
+)
+
+// Event is a change to the state of a single display or audio output
+type Event struct {
+	ID         uint64
+	Type       string
+	RoomID     string
+	ResourceID string
+	Time       time.Time
+
+	// Data is a models.DisplayEvent or models.AudioOutputEvent
+	Data interface{}
+}
+
+// Source provides the current state of a room
+type Source interface {
+	GetRoomState(roomID string) (*models.RoomResourceState, error)
+}
+
+// Hub polls rooms while they have subscribers and fans changes out to them.
+// Recent events are kept so that clients can resume from the last one they saw.
+type Hub struct {
+	Source Source
+
+	// Interval is how often each room is polled
+	Interval time.Duration
+
+	// BufferSize is how many recent events are kept for resuming
+	BufferSize int
+
+	mu     sync.Mutex
+	seq    uint64
+	buffer []Event
+	rooms  map[string]*room
+	subs   map[*Subscription]struct{}
+}
+
+type room struct {
+	subscribers int
+	cancel      context.CancelFunc
+
+	state *models.RoomResourceState
+
+	// latest holds the most recent event for each resource in the room
+	latest map[string]Event
+}
+
+// Subscription receives events for a set of rooms
+type Subscription struct {
+	// Backlog holds the events the subscriber missed (or, for a new
+	// subscriber, the current state of each resource), oldest first
+	Backlog []Event
+
+	// C receives events as they happen. It is closed if the subscriber
+	// falls too far behind, in which case it should reconnect.
+	C <-chan Event
+
+	c     chan Event
+	rooms map[string]bool
+	hub   *Hub
+}
+
+// Subscribe starts streaming events for the given rooms. If lastEventID is
+// non-zero and still buffered, the backlog holds every event since it; otherwise
+// it holds the latest known state of each resource in the rooms.
+func (h *Hub) Subscribe(roomIDs []string, lastEventID uint64) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rooms == nil {
+		h.rooms = map[string]*room{}
+		h.subs = map[*Subscription]struct{}{}
+	}
+
+	c := make(chan Event, 256)
+	sub := &Subscription{
+		C:     c,
+		c:     c,
+		rooms: map[string]bool{},
+		hub:   h,
+	}
+
+	for _, id := range roomIDs {
+		sub.rooms[id] = true
+	}
+
+	resumable := lastEventID > 0 && lastEventID <= h.seq && (len(h.buffer) == 0 || h.buffer[0].ID <= lastEventID+1)
+	if resumable {
+		for _, e := range h.buffer {
+			if e.ID > lastEventID && sub.rooms[e.RoomID] {
+				sub.Backlog = append(sub.Backlog, e)
+			}
+		}
+	} else {
+		for id := range sub.rooms {
+			if r, ok := h.rooms[id]; ok {
+				for _, e := range r.latest {
+					sub.Backlog = append(sub.Backlog, e)
+				}
+			}
+		}
+
+		sort.Slice(sub.Backlog, func(i, j int) bool {
+			return sub.Backlog[i].ID < sub.Backlog[j].ID
+		})
+	}
+
+	for id := range sub.rooms {
+		r, ok := h.rooms[id]
+		if !ok {
+			r = &room{latest: map[string]Event{}}
+			h.rooms[id] = r
+		}
+
+		r.subscribers++
+		if r.cancel == nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			r.cancel = cancel
+			go h.poll(ctx, id)
+		}
+	}
+
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close stops the subscription, and polling of any rooms no one else is subscribed to
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+
+	h.unsubscribe(s)
+}
+
+// unsubscribe must be called with h.mu held
+func (h *Hub) unsubscribe(s *Subscription) {
+	delete(h.subs, s)
+	close(s.c)
+
+	for id := range s.rooms {
+		r := h.rooms[id]
+		r.subscribers--
+		if r.subscribers == 0 {
+			r.cancel()
+			r.cancel = nil
+		}
+	}
+}
+
+func (h *Hub) poll(ctx context.Context, roomID string) {
+	interval := h.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		state, err := h.Source.GetRoomState(roomID)
+		if err != nil {
+			log.Log.Warn("unable to poll room state", zap.String("room", roomID), zap.Error(err))
+		} else {
+			h.update(roomID, state)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update diffs state against the room's previous state and publishes the changes
+func (h *Hub) update(roomID string, state *models.RoomResourceState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[roomID]
+	if !ok || r.cancel == nil {
+		// no one is listening anymore
+		return
+	}
+
+	prev := r.state
+	if prev == nil {
+		prev = &models.RoomResourceState{}
+	}
+	r.state = state
+
+	now := time.Now()
+	for _, id := range sortedKeys(state.Displays) {
+		if old, ok := prev.Displays[id]; ok && old == state.Displays[id] {
+			continue
+		}
+
+		h.publish(r, Event{
+			Type:       TypeDisplay,
+			RoomID:     roomID,
+			ResourceID: id,
+			Time:       now,
+			Data: models.DisplayEvent{
+				DisplayID:    id,
+				DisplayState: state.Displays[id],
+			},
+		})
+	}
+
+	for _, id := range sortedKeys(state.AudioOutputs) {
+		if old, ok := prev.AudioOutputs[id]; ok && old == state.AudioOutputs[id] {
+			continue
+		}
+
+		h.publish(r, Event{
+			Type:       TypeAudioOutput,
+			RoomID:     roomID,
+			ResourceID: id,
+			Time:       now,
+			Data: models.AudioOutputEvent{
+				OutputID:         id,
+				AudioOutputState: state.AudioOutputs[id],
+			},
+		})
+	}
+}
+
+// publish must be called with h.mu held
+func (h *Hub) publish(r *room, e Event) {
+	h.seq++
+	e.ID = h.seq
+
+	size := h.BufferSize
+	if size <= 0 {
+		size = 1000
+	}
+
+	h.buffer = append(h.buffer, e)
+	if len(h.buffer) > size {
+		h.buffer = h.buffer[len(h.buffer)-size:]
+	}
+
+	r.latest[e.ResourceID] = e
+
+	for sub := range h.subs {
+		if !sub.rooms[e.RoomID] {
+			continue
+		}
+
+		select {
+		case sub.c <- e:
+		default:
+			log.Log.Warn("dropping slow event subscriber", zap.String("room", e.RoomID))
+			h.unsubscribe(sub)
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map of states in order
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	sorted := make([]string, len(keys))
+	for i := range keys {
+		sorted[i] = keys[i].String()
+	}
+
+	sort.Strings(sorted)
+	return sorted
+}
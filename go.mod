@@ -1,12 +1,15 @@
 module github.com/byuoitav/uapi-translator
 
-go 1.14
+go 1.16
 
 require (
 	github.com/byuoitav/common v0.0.0-20191210190714-e9b411b3cc0d
 	github.com/fatih/color v1.9.0 // indirect
+	github.com/gorilla/websocket v1.4.2
 	github.com/labstack/echo v3.3.10+incompatible
 	github.com/labstack/gommon v0.3.0 // indirect
 	github.com/spf13/pflag v1.0.5
+	github.com/swaggo/files v1.0.1
 	go.uber.org/zap v1.14.1
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+
+	"github.com/labstack/echo"
+)
+
+//Admin
+
+func (s *Service) GetConfigLint(c echo.Context) error {
+	report, err := s.Services.LintConfig(c.QueryParam("building_abbreviation"))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	log.Log.Infof("found %d configuration issues in %d rooms", len(report.Issues), report.RoomsChecked)
+	return respond(c, report)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+//Buildings
+
+func (s *Service) GetBuildings(c echo.Context) error {
+	if s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	p := parsePage(c)
+	fields := parseFields(c)
+
+	buildings, total, err := s.Services.GetBuildings(c.QueryParam("search"), parseSort(c, "building_abbreviation"), p.Offset, p.Limit, fields)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	log.Log.Infof("successfully retrieved: %d buildings", len(buildings))
+	setPageHeaders(c, p, total)
+	return sparse(c, buildings, fields)
+}
+
+func (s *Service) GetBuildingByID(c echo.Context) error {
+	bldgAbbr := c.Param("building_abbreviation")
+
+	if s.notModified(c, services.BuildingConfig(bldgAbbr)...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	fields := parseFields(c)
+
+	building, err := s.Services.GetBuildingByID(bldgAbbr, fields)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if building == nil {
+		return c.String(http.StatusNotFound, "No buildings exist with the id: "+bldgAbbr)
+	}
+
+	log.Log.Info("successfully retrieved building by id")
+	return sparse(c, building, fields)
+}
+
+func (s *Service) GetBuildingRooms(c echo.Context) error {
+	bldgAbbr := c.Param("building_abbreviation")
+
+	if s.notModified(c, services.BuildingConfig(bldgAbbr)...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	p := parsePage(c)
+	fields := parseFields(c)
+
+	rooms, total, ok, err := s.Services.GetBuildingRooms(bldgAbbr, c.QueryParam("room_number"), c.QueryParam("search"), parseSort(c, "room_number"), p.Offset, p.Limit, fields)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if !ok {
+		return c.String(http.StatusNotFound, "No buildings exist with the id: "+bldgAbbr)
+	}
+
+	log.Log.Infof("successfully retrieved: %d rooms in %s", len(rooms), bldgAbbr)
+	setPageHeaders(c, p, total)
+	return sparse(c, rooms, fields)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+//Cameras
+
+func (s *Service) GetCameras(c echo.Context) error {
+	if s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	cams, err := s.Services.GetCameras(parseFilter(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	log.Log.Infof("successfully retrieved: %d cameras", len(cams))
+	if err := services.Sort(cams, parseSort(c, "building_abbreviation", "room_number", "av_camera_id")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return sparse(c, cams, parseFields(c))
+}
+
+func (s *Service) GetCameraByID(c echo.Context) error {
+	camID := c.Param("av_camera_id")
+
+	if s.notModified(c, services.RoomConfig(avid.Room(camID))...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	cam, err := s.Services.GetCameraByID(camID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if cam == nil {
+		return c.String(http.StatusNotFound, "No cameras exist with the id: "+camID)
+	}
+
+	log.Log.Info("successfully retrieved camera by id")
+	return sparse(c, cam, parseFields(c))
+}
+
+func (s *Service) ControlCamera(c echo.Context) error {
+	camID := c.Param("av_camera_id")
+
+	var control models.CameraControl
+	if err := c.Bind(&control); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	found, err := s.Services.ControlCamera(camID, control)
+
+	var invalid *services.InvalidControlError
+	switch {
+	case errors.As(err, &invalid):
+		return c.JSON(http.StatusBadRequest, invalidResponse{
+			Error: invalid.Error(),
+			Details: openapi.ValidationErrors{
+				{In: "body", Name: invalid.Field, Reason: invalid.Reason},
+			},
+		})
+	case errors.Is(err, services.ErrCameraControl):
+		return c.String(http.StatusBadGateway, err.Error())
+	case err != nil:
+		return c.String(http.StatusInternalServerError, err.Error())
+	case !found:
+		return c.String(http.StatusNotFound, "No cameras exist with the id: "+camID)
+	}
+
+	log.Log.Info("successfully controlled camera")
+	return c.NoContent(http.StatusNoContent)
+}
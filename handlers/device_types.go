@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+//Device Types
+
+func (s *Service) GetDeviceTypes(c echo.Context) error {
+	if s.notModified(c, services.DeviceConfig("")...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	types, err := s.Services.GetDeviceTypes()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	log.Log.Infof("successfully retrieved: %d device types", len(types))
+	if err := services.Sort(types, parseSort(c, "av_device_type_id")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return sparse(c, types, parseFields(c))
+}
+
+func (s *Service) GetDeviceTypeByID(c echo.Context) error {
+	deviceTypeID := c.Param("av_device_type_id")
+
+	if s.notModified(c, services.DeviceConfig("")...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	deviceType, err := s.Services.GetDeviceTypeByID(deviceTypeID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if deviceType == nil {
+		return c.String(http.StatusNotFound, "No device types exist with the id: "+deviceTypeID)
+	}
+
+	log.Log.Info("successfully retrieved device type by id")
+	return sparse(c, deviceType, parseFields(c))
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfNoneMatch = "If-None-Match"
+	HeaderIfMatch     = "If-Match"
+)
+
+var (
+	errPreconditionRequired = errors.New("an If-Match header is required to change this resource")
+	errPreconditionFailed   = errors.New("the resource has changed since it was last retrieved")
+)
+
+// etag returns a strong entity tag made from a hash of parts
+func etag(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// notModified tags a configuration response with the revisions of the couch
+// documents it is built from, and reports whether the client's copy (from
+// If-None-Match) is still current. Since it doesn't need the response, it is
+// called before the response is built. Responses that include state can't be
+// tagged this way; respond tags those with a hash of their body instead.
+func (s *Service) notModified(c echo.Context, ranges ...services.DocRange) bool {
+	version, err := s.Services.Version(ranges...)
+	if err != nil {
+		log.Log.Warn("unable to get the version of the response", zap.Error(err))
+		return false
+	}
+
+	tag := etag(version, c.Request().URL.RawQuery)
+	c.Response().Header().Set(HeaderETag, tag)
+	return matches(c.Request().Header.Get(HeaderIfNoneMatch), tag, true)
+}
+
+// respond responds with v. If the response hasn't already been tagged it is
+// tagged with a hash of its body, and 304 is sent if the client already has it.
+func respond(c echo.Context, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if c.Response().Header().Get(HeaderETag) == "" {
+		tag := etag(string(b))
+		c.Response().Header().Set(HeaderETag, tag)
+
+		if matches(c.Request().Header.Get(HeaderIfNoneMatch), tag, true) {
+			return c.NoContent(http.StatusNotModified)
+		}
+	}
+
+	return c.JSONBlob(http.StatusOK, b)
+}
+
+// bodyETag returns the tag respond would give v
+func bodyETag(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return etag(string(b)), nil
+}
+
+// checkIfMatch makes sure the client is changing the version of something it
+// last saw: current is its tag now, which must be in the request's If-Match.
+// It reports whether the request was refused, in which case a response was sent.
+func checkIfMatch(c echo.Context, current string) (bool, error) {
+	if err := precondition(c, current); err != nil {
+		return true, preconditionError(c, err)
+	}
+
+	return false, nil
+}
+
+// precondition checks the request's If-Match against current, the tag of what
+// is about to be changed, returning errPreconditionRequired or errPreconditionFailed
+// if the change shouldn't be made. The webhook and schedule managers call it
+// while they hold their lock, so two changes from the same version can't both be made.
+func precondition(c echo.Context, current string) error {
+	ifMatch := c.Request().Header.Get(HeaderIfMatch)
+	switch {
+	case ifMatch == "":
+		return errPreconditionRequired
+	case !matches(ifMatch, current, false):
+		c.Response().Header().Set(HeaderETag, current)
+		return errPreconditionFailed
+	}
+
+	return nil
+}
+
+// preconditionError responds to an error from precondition
+func preconditionError(c echo.Context, err error) error {
+	if errors.Is(err, errPreconditionRequired) {
+		return c.String(http.StatusPreconditionRequired, err.Error())
+	}
+
+	return c.String(http.StatusPreconditionFailed, err.Error())
+}
+
+// matches reports whether tag is in list, the value of an If-Match or
+// If-None-Match header. Weak tags (W/"...") only match if weak is set.
+func matches(list, tag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if weak {
+			t = strings.TrimPrefix(t, "W/")
+		}
+
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/events"
+	"github.com/byuoitav/uapi-translator/log"
+
+	"github.com/labstack/echo"
+)
+
+// heartbeatInterval is how often a comment is sent on an idle stream so that
+// proxies don't close it
+const heartbeatInterval = 15 * time.Second
+
+//Events
+
+func (s *Service) GetRoomEvents(c echo.Context) error {
+	roomId := c.Param("room_id")
+
+	_, err := s.Services.DB.GetUIConfig(roomId)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return c.String(http.StatusNotFound, "No rooms exist with the id: "+roomId)
+	case err != nil:
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	log.Log.Infof("streaming events for room %s", roomId)
+	return s.streamEvents(c, []string{roomId})
+}
+
+func (s *Service) GetBuildingEvents(c echo.Context) error {
+	bldgAbbr := c.Param("building_abbreviation")
+
+	rooms, err := s.Services.GetBuildingRoomIDs(bldgAbbr)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if len(rooms) == 0 {
+		return c.String(http.StatusNotFound, "No rooms exist in the building: "+bldgAbbr)
+	}
+
+	log.Log.Infof("streaming events for %d rooms in %s", len(rooms), bldgAbbr)
+	return s.streamEvents(c, rooms)
+}
+
+// streamEvents writes events for the given rooms as server-sent events until the client goes away
+func (s *Service) streamEvents(c echo.Context, rooms []string) error {
+	lastID, _ := strconv.ParseUint(c.Request().Header.Get("Last-Event-ID"), 10, 64)
+
+	sub := s.Events.Subscribe(rooms, lastID)
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	// ask clients to wait a bit before reconnecting
+	fmt.Fprint(res, "retry: 5000\n\n")
+
+	for _, e := range sub.Backlog {
+		if err := writeEvent(res, e); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				// the client fell behind; it will reconnect with its last event id
+				return nil
+			}
+
+			if err := writeEvent(res, e); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		}
+
+		res.Flush()
+	}
+}
+
+func writeEvent(res *echo.Response, e events.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("handlers/writeEvent marshal: %w", err)
+	}
+
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
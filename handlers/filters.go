@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+// parseFilter reads the collection filters out of the query parameters
+func parseFilter(c echo.Context) services.Filter {
+	f := services.Filter{
+		BldgAbbrs:    queryList(c, "building_abbreviation"),
+		RoomNum:      c.QueryParam("room_number"),
+		DeviceType:   c.QueryParam("av_device_type"),
+		Roles:        queryList(c, "role"),
+		Designations: queryList(c, "designation"),
+		Search:       c.QueryParam("search"),
+		HasResources: queryList(c, "has_resource"),
+	}
+
+	for _, tag := range c.QueryParams()["tag"] {
+		f.Tags = append(f.Tags, services.ParseTag(tag))
+	}
+
+	return f
+}
+
+// queryList returns the values of a query parameter that may be repeated, or hold a comma separated list
+func queryList(c echo.Context, name string) []string {
+	var list []string
+	for _, param := range c.QueryParams()[name] {
+		for _, v := range strings.Split(param, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+
+	return list
+}
+
+// expanding reports whether name is one of the values of the expand query parameter
+func expanding(c echo.Context, name string) bool {
+	for _, v := range queryList(c, "expand") {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSort reads the sort query parameter. The collection's default order, def,
+// breaks any ties so the same items always come back in the same order.
+func parseSort(c echo.Context, def ...string) []services.SortKey {
+	return append(services.ParseSort(queryList(c, "sort")), services.ParseSort(def)...)
+}
+
+const (
+	// defaultListLimit is how many items a collection returns
+	defaultListLimit = 30
+
+	// roomListLimit is how many items a collection returns when it is narrowed
+	// to a room number, which is enough for everything in the rooms with it
+	roomListLimit = 1000
+)
+
+// listLimit is how many items a collection returns, after every match has been sorted
+func listLimit(f services.Filter) int {
+	if f.RoomNum != "" {
+		return roomListLimit
+	}
+
+	return defaultListLimit
+}
+
+// sortsBy reports whether any of the keys sort by one of the properties, or by something in one of them
+func sortsBy(keys []services.SortKey, props ...string) bool {
+	for _, key := range keys {
+		for _, p := range props {
+			if key.Property == p || strings.HasPrefix(key.Property, p+".") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseFields reads the fields query parameter. The properties filled in by
+// expand are always kept, so they don't need to be listed too. The fields are
+// nil if every property is wanted.
+func parseFields(c echo.Context, expanded ...string) services.Fields {
+	names := queryList(c, "fields")
+	if len(names) == 0 {
+		return nil
+	}
+
+	return services.NewFields(append(names, expanded...)...)
+}
+
+// sparse responds with v, keeping only the wanted properties of it (or of each item in it)
+func sparse(c echo.Context, v interface{}, fields services.Fields) error {
+	if fields == nil {
+		return respond(c, v)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	var generic interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&generic); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	switch val := generic.(type) {
+	case []interface{}:
+		for _, item := range val {
+			trim(item, fields)
+		}
+	default:
+		trim(val, fields)
+	}
+
+	return respond(c, generic)
+}
+
+// trim removes the properties of obj that aren't wanted
+func trim(obj interface{}, fields services.Fields) {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for k := range m {
+		if !fields.Has(k) {
+			delete(m, k)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+//Groups
+
+func (s *Service) GetRoomGroups(c echo.Context) error {
+	roomID := c.Param("room_id")
+
+	groups, err := s.Services.GetRoomGroups(roomID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if groups == nil {
+		return c.String(http.StatusNotFound, "No rooms exist with the id: "+roomID)
+	}
+
+	log.Log.Infof("successfully retrieved: %d display groups", len(groups))
+	return respond(c, groups)
+}
+
+func (s *Service) SetRoomGroup(c echo.Context) error {
+	roomID := c.Param("room_id")
+	dispID := c.Param("av_display_id")
+
+	var update models.DisplayGroupUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// the groups may only be changed from a version the client has seen
+	current, err := s.Services.GetRoomGroups(roomID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if current == nil {
+		return c.String(http.StatusNotFound, "No rooms exist with the id: "+roomID)
+	}
+
+	tag, err := bodyETag(current)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := precondition(c, tag); err != nil {
+		return preconditionError(c, err)
+	}
+
+	group, err := s.Services.SetRoomGroup(roomID, dispID, update)
+
+	var invalid *services.InvalidControlError
+	switch {
+	case errors.As(err, &invalid):
+		return c.JSON(http.StatusBadRequest, invalidResponse{
+			Error: invalid.Error(),
+			Details: openapi.ValidationErrors{
+				{In: "body", Name: invalid.Field, Reason: invalid.Reason},
+			},
+		})
+	case errors.Is(err, services.ErrDisplayNotShowing):
+		return c.String(http.StatusConflict, err.Error())
+	case err != nil:
+		return c.String(http.StatusInternalServerError, err.Error())
+	case group == nil:
+		return c.String(http.StatusNotFound, "No displays exist with the id: "+dispID)
+	}
+
+	log.Log.Info("successfully set display group")
+	return respond(c, group)
+}
@@ -4,165 +4,329 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/events"
+	"github.com/byuoitav/uapi-translator/health"
+	"github.com/byuoitav/uapi-translator/history"
 	"github.com/byuoitav/uapi-translator/log"
 	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/schedules"
 	"github.com/byuoitav/uapi-translator/services"
+	"github.com/byuoitav/uapi-translator/webhooks"
 
 	"github.com/labstack/echo"
 )
 
 type Service struct {
-	Services *services.Service
+	Services  *services.Service
+	Events    *events.Hub
+	Webhooks  *webhooks.Manager
+	Schedules *schedules.Manager
+
+	// History serves recorded state. If it is nil, no state is recorded.
+	History *history.Recorder
+
+	// Health serves device health. If it is nil, no devices are monitored.
+	Health *health.Monitor
+
+	// Authorizer checks each websocket subscription and command. If it
+	// is nil, everything is allowed.
+	Authorizer Authorizer
 }
 
 //Rooms
 
 func (s *Service) GetRooms(c echo.Context) error {
-	roomNum := c.QueryParam("room_number")
-	bldgAbbr := c.QueryParam("building_abbreviation")
+	if !expanding(c, services.ExpandState) && s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	f := parseFilter(c)
+	fields := parseFields(c, "av_room_devices", "av_room_state")
+	keys := parseSort(c, "building_abbreviation", "room_number")
 
-	rooms, err := s.Services.GetRooms(roomNum, bldgAbbr)
+	rooms, err := s.Services.GetRooms(f)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	// what is looked up for each room is only looked up for
+	// the rooms that are returned, unless it is sorted by
+	lookup := func(rooms []models.Room) error {
+		if fields.Has("av_resources") {
+			if err := s.Services.AddRoomResources(rooms); err != nil {
+				return err
+			}
+		}
+
+		return s.Services.ExpandRooms(rooms, queryList(c, "expand"))
+	}
+
+	early := sortsBy(keys, "av_resources", "av_room_devices", "av_room_state")
+	if early {
+		if err := lookup(rooms); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	log.Log.Infof("successfully retrieved: %d rooms", len(rooms))
-	return c.JSON(http.StatusOK, rooms)
+	if err := services.Sort(rooms, keys); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if limit := listLimit(f); len(rooms) > limit {
+		rooms = rooms[:limit]
+	}
+
+	if !early {
+		if err := lookup(rooms); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return sparse(c, rooms, fields)
 }
 
 func (s *Service) GetRoomByID(c echo.Context) error {
 	roomId := c.Param("room_id")
 	parts := strings.Split(roomId, "-")
 
-	room, err := s.Services.GetRooms(parts[1], parts[0])
+	if !expanding(c, services.ExpandState) && s.notModified(c, services.RoomConfig(roomId)...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	fields := parseFields(c, "av_room_devices", "av_room_state")
+
+	rooms, err := s.Services.GetRooms(services.Filter{BldgAbbrs: parts[:1], RoomNum: parts[1]})
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	if len(rooms) == 0 {
+		return c.String(http.StatusNotFound, "No rooms exist with the id: "+roomId)
+	}
+
+	if fields.Has("av_resources") {
+		if err := s.Services.AddRoomResources(rooms[:1]); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if err := s.Services.ExpandRooms(rooms[:1], queryList(c, "expand")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
 	log.Log.Info("successfully retrieved room by id")
-	return c.JSON(http.StatusOK, room)
+	return sparse(c, rooms[0], fields)
 }
 
 func (s *Service) GetRoomDevices(c echo.Context) error {
 	roomId := c.Param("room_id")
 
+	if s.notModified(c, services.RoomConfig(roomId)...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	devices, err := s.Services.GetRoomDevices(roomId)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	log.Log.Info("successfully retrieved room devices")
-	return c.JSON(http.StatusOK, devices)
+	return respond(c, devices)
 }
 
 //Devices
 
 func (s *Service) GetDevices(c echo.Context) error {
-	roomNum := c.QueryParam("room_number")
-	bldgAbbr := c.QueryParam("building_abbreviation")
-	deviceType := c.QueryParam("av_device_type")
+	f := parseFilter(c)
+
+	// only known device types can be searched for
+	if f.DeviceType != "" {
+		dt, err := s.Services.GetDeviceTypeByID(f.DeviceType)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+
+		if dt == nil {
+			return c.JSON(http.StatusBadRequest, invalidResponse{
+				Error: "unknown device type: " + f.DeviceType,
+				Details: openapi.ValidationErrors{
+					{In: "query", Name: "av_device_type", Reason: "is not a known device type"},
+				},
+			})
+		}
+	}
+
+	if s.notModified(c, services.DeviceConfig("")...) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
-	devices, err := s.Services.GetDevices(roomNum, bldgAbbr, deviceType)
+	devices, err := s.Services.GetDevices(f)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	log.Log.Infof("successfully retrieved: %d devices", len(devices))
-	return c.JSON(http.StatusOK, devices)
+	if err := services.Sort(devices, parseSort(c, "building_abbreviation", "room_number", "av_device_id")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if limit := listLimit(f); len(devices) > limit {
+		devices = devices[:limit]
+	}
+
+	return sparse(c, devices, parseFields(c))
 }
 
 func (s *Service) GetDeviceByID(c echo.Context) error {
 	deviceId := c.Param("av_device_id")
 
+	if s.notModified(c, services.DeviceConfig(deviceId)...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	device, err := s.Services.GetDeviceByID(deviceId)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	log.Log.Info("successfully retrieved device by id")
-	return c.JSON(http.StatusOK, device)
+	return sparse(c, device, parseFields(c))
 }
 
 func (s *Service) GetDeviceProperties(c echo.Context) error {
 	// deviceId := c.Param("av_device_id")
 
-	var deviceProperties []models.DeviceProperty
-	return c.JSON(http.StatusOK, deviceProperties)
+	deviceProperties := []models.DeviceProperty{}
+	return respond(c, deviceProperties)
 }
 
 func (s *Service) GetDeviceState(c echo.Context) error {
 	// deviceId := c.Param("av_device_id")
 
-	var deviceStateAttrs []models.DeviceStateAttribute
-	return c.JSON(http.StatusOK, deviceStateAttrs)
+	deviceStateAttrs := []models.DeviceStateAttribute{}
+	return respond(c, deviceStateAttrs)
 }
 
 //Inputs
 
 func (s *Service) GetInputs(c echo.Context) error {
+	if s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
-	roomNum := c.QueryParam("room_number")
-	bldgAbbr := c.QueryParam("building_abbreviation")
-
-	inputs, err := s.Services.GetInputs(roomNum, bldgAbbr)
+	f := parseFilter(c)
+	inputs, err := s.Services.GetInputs(f)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	log.Log.Infof("successfully retrieved: %d inputs", len(inputs))
-	return c.JSON(http.StatusOK, inputs)
+	if err := services.Sort(inputs, parseSort(c, "building_abbreviation", "room_number")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if limit := listLimit(f); len(inputs) > limit {
+		inputs = inputs[:limit]
+	}
+
+	return sparse(c, inputs, parseFields(c))
 }
 
 func (s *Service) GetInputByID(c echo.Context) error {
 	deviceId := c.Param("av_device_id")
 
+	if s.notModified(c, services.RoomConfig(avid.Room(deviceId))...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	input, err := s.Services.GetInputByID(deviceId)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	log.Log.Info("successfully retrieved input by id")
-	return c.JSON(http.StatusOK, input)
+	return sparse(c, input, parseFields(c))
 }
 
 //Displays
 
 func (s *Service) GetDisplays(c echo.Context) error {
+	if !expanding(c, services.ExpandState) && s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
-	roomNum := c.QueryParam("room_number")
-	bldgAbbr := c.QueryParam("building_abbreviation")
+	f := parseFilter(c)
+	keys := parseSort(c, "building_abbreviation", "room_number", "av_display_id")
 
-	displays, err := s.Services.GetDisplays(roomNum, bldgAbbr)
+	displays, err := s.Services.GetDisplays(f)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	// only the displays that are returned are expanded, unless they are sorted by what is expanded
+	early := sortsBy(keys, "av_display_config", "av_display_state")
+	if early {
+		if err := s.Services.ExpandDisplays(displays, queryList(c, "expand")); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	log.Log.Infof("successfully retrieved: %d displays", len(displays))
-	return c.JSON(http.StatusOK, displays)
+	if err := services.Sort(displays, keys); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if limit := listLimit(f); len(displays) > limit {
+		displays = displays[:limit]
+	}
+
+	if !early {
+		if err := s.Services.ExpandDisplays(displays, queryList(c, "expand")); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return sparse(c, displays, parseFields(c, "av_display_config", "av_display_state"))
 }
 
 func (s *Service) GetDisplayByID(c echo.Context) error {
 	displayId := c.Param("av_display_id")
 
+	if !expanding(c, services.ExpandState) && s.notModified(c, services.RoomConfig(avid.Room(displayId))...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	display, err := s.Services.GetDisplayByID(displayId)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	displays := []models.Display{*display}
+	if err := s.Services.ExpandDisplays(displays, queryList(c, "expand")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
 	log.Log.Info("successfully retrieved display by id")
-	return c.JSON(http.StatusOK, display)
+	return sparse(c, displays[0], parseFields(c, "av_display_config", "av_display_state"))
 }
 
 func (s *Service) GetDisplayConfig(c echo.Context) error {
 	displayId := c.Param("av_display_id")
 
+	if s.notModified(c, services.RoomConfig(avid.Room(displayId))...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	displayConfig, err := s.Services.GetDisplayConfig(displayId)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	log.Log.Info("successfully retrieved display config")
-	return c.JSON(http.StatusOK, displayConfig)
+	return respond(c, displayConfig)
 }
 
 func (s *Service) GetDisplayState(c echo.Context) error {
@@ -174,35 +338,81 @@ func (s *Service) GetDisplayState(c echo.Context) error {
 	}
 
 	log.Log.Info("successfully retrieved display state")
-	return c.JSON(http.StatusOK, displayState)
+	return respond(c, displayState)
+}
+
+func (s *Service) BatchGetDisplayStates(c echo.Context) error {
+	var req models.DisplayStateBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	results := s.Services.BatchGetDisplayStates(req.DisplayIDs)
+
+	log.Log.Infof("successfully retrieved batch of %d display states", len(results))
+	return c.JSON(http.StatusOK, results)
 }
 
 //Audio Outputs
 
 func (s *Service) GetAudioOutputs(c echo.Context) error {
-	roomNum := c.QueryParam("room_number")
-	bldgAbbr := c.QueryParam("building_abbreviation")
-	deviceType := c.QueryParam("av_device_type")
+	if !expanding(c, services.ExpandState) && s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	f := parseFilter(c)
+	keys := parseSort(c, "building_abbreviation", "room_number", "av_audio_output_id")
 
-	outputs, err := s.Services.GetAudioOutputs(roomNum, bldgAbbr, deviceType)
+	outputs, err := s.Services.GetAudioOutputs(f)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	// only the audio outputs that are returned are expanded, unless they are sorted by what is expanded
+	early := sortsBy(keys, "av_audio_output_state")
+	if early {
+		if err := s.Services.ExpandAudioOutputs(outputs, queryList(c, "expand")); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	log.Log.Infof("successfully retrieved: %d audio outputs", len(outputs))
-	return c.JSON(http.StatusOK, outputs)
+	if err := services.Sort(outputs, keys); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if limit := listLimit(f); len(outputs) > limit {
+		outputs = outputs[:limit]
+	}
+
+	if !early {
+		if err := s.Services.ExpandAudioOutputs(outputs, queryList(c, "expand")); err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return sparse(c, outputs, parseFields(c, "av_audio_output_state"))
 }
 
 func (s *Service) GetAudioOutputByID(c echo.Context) error {
 	outputId := c.Param("av_audio_output_id")
 
+	if !expanding(c, services.ExpandState) && s.notModified(c, services.RoomConfig(avid.Room(outputId))...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	output, err := s.Services.GetAudioOutputByID(outputId)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	outputs := []models.AudioOutput{*output}
+	if err := s.Services.ExpandAudioOutputs(outputs, queryList(c, "expand")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
 	log.Log.Info("successfully retrieved audio output by id")
-	return c.JSON(http.StatusOK, output)
+	return sparse(c, outputs[0], parseFields(c, "av_audio_output_state"))
 }
 
 func (s *Service) GetAudioOutputState(c echo.Context) error {
@@ -214,5 +424,17 @@ func (s *Service) GetAudioOutputState(c echo.Context) error {
 	}
 
 	log.Log.Info("successfully retrieved audio output state by id")
-	return c.JSON(http.StatusOK, outputState)
+	return respond(c, outputState)
+}
+
+func (s *Service) BatchGetAudioOutputStates(c echo.Context) error {
+	var req models.AudioOutputStateBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	results := s.Services.BatchGetAudioOutputStates(req.OutputIDs)
+
+	log.Log.Infof("successfully retrieved batch of %d audio output states", len(results))
+	return c.JSON(http.StatusOK, results)
 }
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+
+	"github.com/labstack/echo"
+)
+
+//Health
+
+func (s *Service) GetDeviceHealth(c echo.Context) error {
+	deviceId := c.Param("av_device_id")
+
+	if !s.Health.Monitoring(deviceId) {
+		return c.String(http.StatusNotFound, "Health isn't monitored for the device: "+deviceId)
+	}
+
+	h := s.Health.Health(deviceId)
+	if h == nil {
+		return c.String(http.StatusNotFound, "No devices have been checked with the id: "+deviceId)
+	}
+
+	log.Log.Infof("successfully retrieved health of %s", deviceId)
+	return c.JSON(http.StatusOK, h)
+}
+
+func (s *Service) GetHealthSummary(c echo.Context) error {
+	if s.Health == nil {
+		return c.String(http.StatusNotFound, "Device health isn't monitored")
+	}
+
+	sum := s.Health.Summary()
+
+	log.Log.Infof("successfully summarized health of %d devices", sum.Devices)
+	return c.JSON(http.StatusOK, sum)
+}
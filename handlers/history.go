@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/openapi"
+
+	"github.com/labstack/echo"
+)
+
+// defaultHistoryRange is how far back history goes when from isn't given
+const defaultHistoryRange = 24 * time.Hour
+
+//History
+
+func (s *Service) GetDisplayStateHistory(c echo.Context) error {
+	displayId := c.Param("av_display_id")
+
+	from, to, ok, err := parseTimeRange(c, defaultHistoryRange)
+	if !ok {
+		return err
+	}
+
+	if !s.History.Recording(avid.Room(displayId)) {
+		return c.String(http.StatusNotFound, "State history isn't recorded for the display: "+displayId)
+	}
+
+	h := s.History.DisplayHistory(displayId, from, to)
+
+	log.Log.Infof("successfully retrieved %d display states", len(h.States))
+	return c.JSON(http.StatusOK, h)
+}
+
+func (s *Service) GetAudioOutputStateHistory(c echo.Context) error {
+	outputId := c.Param("av_audio_output_id")
+
+	from, to, ok, err := parseTimeRange(c, defaultHistoryRange)
+	if !ok {
+		return err
+	}
+
+	if !s.History.Recording(avid.Room(outputId)) {
+		return c.String(http.StatusNotFound, "State history isn't recorded for the audio output: "+outputId)
+	}
+
+	h := s.History.AudioOutputHistory(outputId, from, to)
+
+	log.Log.Infof("successfully retrieved %d audio output states", len(h.States))
+	return c.JSON(http.StatusOK, h)
+}
+
+// parseTimeRange reads the from and to query parameters, which are RFC 3339
+// times. to defaults to now and from to def before to. If they are invalid a
+// response has been sent and ok is false.
+func parseTimeRange(c echo.Context, def time.Duration) (from, to time.Time, ok bool, err error) {
+	invalid := func(name, reason string) (time.Time, time.Time, bool, error) {
+		return from, to, false, c.JSON(http.StatusBadRequest, invalidResponse{
+			Error: "invalid " + name + ": " + reason,
+			Details: openapi.ValidationErrors{
+				{In: "query", Name: name, Reason: reason},
+			},
+		})
+	}
+
+	to = time.Now().UTC()
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return invalid("to", "must be an RFC 3339 time")
+		}
+	}
+
+	from = to.Add(-def)
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return invalid("from", "must be an RFC 3339 time")
+		}
+	}
+
+	if !from.Before(to) {
+		return invalid("from", "must be before to")
+	}
+
+	return from.UTC(), to.UTC(), true, nil
+}
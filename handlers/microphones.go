@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+//Microphones
+
+func (s *Service) GetMicrophones(c echo.Context) error {
+	if s.notModified(c, services.AllConfig...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	mics, err := s.Services.GetMicrophones(parseFilter(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	log.Log.Infof("successfully retrieved: %d microphones", len(mics))
+	if err := services.Sort(mics, parseSort(c, "building_abbreviation", "room_number", "av_microphone_id")); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return sparse(c, mics, parseFields(c))
+}
+
+func (s *Service) GetMicrophoneByID(c echo.Context) error {
+	micID := c.Param("av_microphone_id")
+
+	if s.notModified(c, services.RoomConfig(avid.Room(micID))...) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	mic, err := s.Services.GetMicrophoneByID(micID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if mic == nil {
+		return c.String(http.StatusNotFound, "No microphones exist with the id: "+micID)
+	}
+
+	log.Log.Info("successfully retrieved microphone by id")
+	return sparse(c, mic, parseFields(c))
+}
+
+func (s *Service) GetMicrophoneState(c echo.Context) error {
+	micID := c.Param("av_microphone_id")
+
+	state, err := s.Services.GetMicrophoneState(micID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if state == nil {
+		return c.String(http.StatusNotFound, "No microphones exist with the id: "+micID)
+	}
+
+	log.Log.Info("successfully retrieved microphone state")
+	return respond(c, state)
+}
+
+func (s *Service) SetMicrophoneState(c echo.Context) error {
+	micID := c.Param("av_microphone_id")
+
+	var update models.MicrophoneStateUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// the state may only be changed from a version the client has seen
+	current, err := s.Services.GetMicrophoneState(micID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if current == nil {
+		return c.String(http.StatusNotFound, "No microphones exist with the id: "+micID)
+	}
+
+	tag, err := bodyETag(current)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if refused, err := checkIfMatch(c, tag); refused {
+		return err
+	}
+
+	state, err := s.Services.SetMicrophoneState(micID, update)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if state == nil {
+		return c.String(http.StatusNotFound, "No microphones exist with the id: "+micID)
+	}
+
+	log.Log.Info("successfully set microphone state")
+	return respond(c, state)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/openapi"
+	swaggerFiles "github.com/swaggo/files"
+
+	"github.com/labstack/echo"
+)
+
+//go:embed docs.html
+var docsIndex []byte
+
+// Docs serves the OpenAPI spec and an interactive UI for browsing it
+type Docs struct {
+	Spec *openapi.Spec
+}
+
+func (d *Docs) GetSpecYAML(c echo.Context) error {
+	b, err := d.Spec.YAML(serverURL(c))
+	if err != nil {
+		log.Log.Errorf("Unable to build OpenAPI spec: %s", err)
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Blob(http.StatusOK, "application/yaml", b)
+}
+
+func (d *Docs) GetSpecJSON(c echo.Context) error {
+	b, err := d.Spec.JSON(serverURL(c))
+	if err != nil {
+		log.Log.Errorf("Unable to build OpenAPI spec: %s", err)
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSONBlob(http.StatusOK, b)
+}
+
+// GetDocs serves the bundled swagger-ui, which is pointed at /openapi.json
+func (d *Docs) GetDocs(c echo.Context) error {
+	// The UI's assets are all relative to /docs/
+	if !strings.HasSuffix(c.Request().URL.Path, "/") && c.Param("*") == "" {
+		return c.Redirect(http.StatusMovedPermanently, c.Request().URL.Path+"/")
+	}
+
+	switch c.Param("*") {
+	case "", "index.html":
+		return c.HTMLBlob(http.StatusOK, docsIndex)
+	}
+
+	http.StripPrefix("/docs/", http.FileServer(swaggerFiles.HTTP)).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// serverURL returns the base url the request was made against
+func serverURL(c echo.Context) string {
+	return fmt.Sprintf("%s://%s", c.Scheme(), c.Request().Host)
+}
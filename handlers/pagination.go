@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	defaultPageLimit = 100
+
+	// HeaderTotalCount holds the number of items in a paginated collection
+	HeaderTotalCount = "X-Total-Count"
+)
+
+// page is the part of a collection requested with the offset and limit query parameters
+type page struct {
+	Offset int
+	Limit  int
+}
+
+// parsePage reads the offset and limit query parameters. Their ranges are checked by the validator.
+func parsePage(c echo.Context) page {
+	p := page{Limit: defaultPageLimit}
+
+	if v, err := strconv.Atoi(c.QueryParam("offset")); err == nil && v >= 0 {
+		p.Offset = v
+	}
+
+	if v, err := strconv.Atoi(c.QueryParam("limit")); err == nil && v > 0 {
+		p.Limit = v
+	}
+
+	return p
+}
+
+// setPageHeaders adds the total count, and links to the next and previous pages, to the response
+func setPageHeaders(c echo.Context, p page, total int) {
+	c.Response().Header().Set(HeaderTotalCount, strconv.Itoa(total))
+
+	link := func(offset int, rel string) string {
+		u := url.URL{Path: c.Request().URL.Path}
+		q := c.Request().URL.Query()
+		q.Set("offset", strconv.Itoa(offset))
+		q.Set("limit", strconv.Itoa(p.Limit))
+		u.RawQuery = q.Encode()
+
+		return fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel)
+	}
+
+	var links []string
+	if p.Offset+p.Limit < total {
+		links = append(links, link(p.Offset+p.Limit, "next"))
+	}
+
+	if p.Offset > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(prev, "prev"))
+	}
+
+	for _, l := range links {
+		c.Response().Header().Add("Link", l)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// Register adds every AV API route to the given group
+func (s *Service) Register(g *echo.Group) {
+	//Rooms
+	g.GET("/rooms", s.GetRooms)
+	g.GET("/rooms/:room_id", s.GetRoomByID)
+	g.GET("/rooms/:room_id/devices", s.GetRoomDevices)
+
+	//Buildings
+	g.GET("/buildings", s.GetBuildings)
+	g.GET("/buildings/:building_abbreviation", s.GetBuildingByID)
+	g.GET("/buildings/:building_abbreviation/rooms", s.GetBuildingRooms)
+
+	//Devices
+	g.GET("/devices", s.GetDevices)
+	g.GET("/devices/:av_device_id", s.GetDeviceByID)
+	g.GET("/devices/:av_device_id/properties", s.GetDeviceProperties)
+	g.GET("/devices/:av_device_id/state", s.GetDeviceState)
+	g.GET("/devices/:av_device_id/health", s.GetDeviceHealth)
+
+	//Device Types
+	g.GET("/device_types", s.GetDeviceTypes)
+	g.GET("/device_types/:av_device_type_id", s.GetDeviceTypeByID)
+
+	//Inputs
+	g.GET("/inputs", s.GetInputs)
+	g.GET("/inputs/:av_device_id", s.GetInputByID)
+
+	//Displays
+	g.GET("/displays", s.GetDisplays)
+	g.GET("/displays/:av_display_id", s.GetDisplayByID)
+	g.GET("/displays/:av_display_id/config", s.GetDisplayConfig)
+	g.GET("/displays/:av_display_id/state", s.GetDisplayState)
+	g.GET("/displays/:av_display_id/state/history", s.GetDisplayStateHistory)
+	g.POST("/displays/state:batchGet", literal(s.BatchGetDisplayStates))
+
+	//Audio Outputs
+	g.GET("/audio_outputs", s.GetAudioOutputs)
+	g.GET("/audio_outputs/:av_audio_output_id", s.GetAudioOutputByID)
+	g.GET("/audio_outputs/:av_audio_output_id/state", s.GetAudioOutputState)
+	g.GET("/audio_outputs/:av_audio_output_id/state/history", s.GetAudioOutputStateHistory)
+	g.POST("/audio_outputs/state:batchGet", literal(s.BatchGetAudioOutputStates))
+
+	//Microphones
+	g.GET("/microphones", s.GetMicrophones)
+	g.GET("/microphones/:av_microphone_id", s.GetMicrophoneByID)
+	g.GET("/microphones/:av_microphone_id/state", s.GetMicrophoneState)
+	g.PUT("/microphones/:av_microphone_id/state", s.SetMicrophoneState)
+
+	//Usage
+	g.GET("/rooms/:room_id/usage", s.GetRoomUsage)
+	g.GET("/buildings/:building_abbreviation/usage", s.GetBuildingUsage)
+
+	//Groups
+	g.GET("/rooms/:room_id/groups", s.GetRoomGroups)
+	g.PUT("/rooms/:room_id/groups/:av_display_id", s.SetRoomGroup)
+
+	//Scenes
+	g.GET("/rooms/:room_id/scenes", s.GetScenes)
+	g.PUT("/rooms/:room_id/scenes", s.SetScenes)
+	g.POST("/rooms/:room_id/scenes/:scene:apply", s.ApplyScene)
+
+	//Cameras
+	g.GET("/cameras", s.GetCameras)
+	g.GET("/cameras/:av_camera_id", s.GetCameraByID)
+	g.POST("/cameras/:av_camera_id/control", s.ControlCamera)
+
+	//Events
+	g.GET("/rooms/:room_id/events", s.GetRoomEvents)
+	g.GET("/buildings/:building_abbreviation/events", s.GetBuildingEvents)
+
+	//Webhooks
+	g.GET("/webhooks", s.GetWebhooks)
+	g.POST("/webhooks", s.CreateWebhook)
+	g.GET("/webhooks/:webhook_id", s.GetWebhookByID)
+	g.PUT("/webhooks/:webhook_id", s.UpdateWebhook)
+	g.DELETE("/webhooks/:webhook_id", s.DeleteWebhook)
+	g.GET("/webhooks/:webhook_id/dead_letters", s.GetWebhookDeadLetters)
+	g.POST("/webhooks/:webhook_id/dead_letters/:delivery_id/redeliver", s.RedeliverWebhookDeadLetter)
+
+	//Schedules
+	g.GET("/schedules", s.GetSchedules)
+	g.POST("/schedules", s.CreateSchedule)
+	g.GET("/schedules/:schedule_id", s.GetScheduleByID)
+	g.PUT("/schedules/:schedule_id", s.UpdateSchedule)
+	g.DELETE("/schedules/:schedule_id", s.DeleteSchedule)
+	g.GET("/schedules/:schedule_id/runs", s.GetScheduleRuns)
+
+	//Health
+	g.GET("/health/summary", s.GetHealthSummary)
+
+	//Admin
+	g.GET("/admin/lint", s.GetConfigLint)
+
+	//Websocket
+	g.GET("/ws", s.GetWebsocket)
+}
+
+// Register adds the spec and docs routes to the router
+func (d *Docs) Register(e *echo.Echo) {
+	e.GET("/openapi.yaml", d.GetSpecYAML)
+	e.GET("/openapi.json", d.GetSpecJSON)
+	e.GET("/docs", d.GetDocs)
+	e.GET("/docs/*", d.GetDocs)
+}
+
+// literal only lets through requests for exactly the route's path. echo reads
+// the :batchGet in /displays/state:batchGet as a parameter, so without it
+// /displays/stateful would be routed there too.
+func literal(h echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !strings.HasSuffix(c.Request().URL.Path, c.Path()) {
+			return echo.ErrNotFound
+		}
+
+		return h(c)
+	}
+}
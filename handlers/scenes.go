@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/scenes"
+	"github.com/byuoitav/uapi-translator/services"
+
+	"github.com/labstack/echo"
+)
+
+//Scenes
+
+func (s *Service) GetScenes(c echo.Context) error {
+	roomID := c.Param("room_id")
+
+	scenes, version, err := s.Services.GetScenes(roomID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if scenes == nil {
+		return c.String(http.StatusNotFound, "No rooms exist with the id: "+roomID)
+	}
+
+	tag := sceneETag(version)
+	c.Response().Header().Set(HeaderETag, tag)
+	if matches(c.Request().Header.Get(HeaderIfNoneMatch), tag, true) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	log.Log.Infof("successfully retrieved: %d scenes", len(scenes))
+	return respond(c, scenes)
+}
+
+func (s *Service) SetScenes(c echo.Context) error {
+	roomID := c.Param("room_id")
+
+	var in []models.Scene
+	if err := c.Bind(&in); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	// the scenes are configuration, so they may only be replaced from a version the client has seen
+	saved, version, err := s.Services.SetScenes(roomID, in, func(version string) error {
+		return precondition(c, sceneETag(version))
+	})
+
+	var invalid *services.InvalidControlError
+	switch {
+	case errors.As(err, &invalid):
+		return c.JSON(http.StatusBadRequest, invalidResponse{
+			Error: invalid.Error(),
+			Details: openapi.ValidationErrors{
+				{In: "body", Name: invalid.Field, Reason: invalid.Reason},
+			},
+		})
+	case errors.Is(err, errPreconditionRequired), errors.Is(err, errPreconditionFailed):
+		return preconditionError(c, err)
+	case errors.Is(err, scenes.ErrConflict):
+		return preconditionError(c, errPreconditionFailed)
+	case err != nil:
+		return c.String(http.StatusInternalServerError, err.Error())
+	case saved == nil:
+		return c.String(http.StatusNotFound, "No rooms exist with the id: "+roomID)
+	}
+
+	log.Log.Infof("successfully set: %d scenes", len(saved))
+	c.Response().Header().Set(HeaderETag, sceneETag(version))
+	return respond(c, saved)
+}
+
+func (s *Service) ApplyScene(c echo.Context) error {
+	roomID := c.Param("room_id")
+	name := c.Param("scene")
+
+	state, err := s.Services.ApplyScene(roomID, name)
+	switch {
+	case errors.Is(err, services.ErrSceneOutdated):
+		return c.String(http.StatusConflict, err.Error())
+	case err != nil:
+		return c.String(http.StatusInternalServerError, err.Error())
+	case state == nil:
+		return c.String(http.StatusNotFound, "No scenes exist with the name: "+name)
+	}
+
+	log.Log.Info("successfully applied scene")
+	return respond(c, state)
+}
+
+// sceneETag returns the tag of a room's scenes, from their version in the store
+func sceneETag(version string) string {
+	return `"` + version + `"`
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/schedules"
+
+	"github.com/labstack/echo"
+)
+
+//Schedules
+
+func (s *Service) GetSchedules(c echo.Context) error {
+	scheds := s.Schedules.List()
+
+	log.Log.Infof("successfully retrieved: %d schedules", len(scheds))
+	return c.JSON(http.StatusOK, scheds)
+}
+
+func (s *Service) CreateSchedule(c echo.Context) error {
+	var in models.ScheduleInput
+	if err := c.Bind(&in); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	sched, err := s.Schedules.Create(in)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	log.Log.Infof("created schedule %s", sched.ScheduleID)
+	return c.JSON(http.StatusCreated, sched)
+}
+
+func (s *Service) GetScheduleByID(c echo.Context) error {
+	sched, err := s.Schedules.Get(c.Param("schedule_id"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return respond(c, sched)
+}
+
+func (s *Service) UpdateSchedule(c echo.Context) error {
+	var in models.ScheduleInput
+	if err := c.Bind(&in); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	sched, err := s.Schedules.Update(c.Param("schedule_id"), in, scheduleIfMatch(c))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	log.Log.Infof("updated schedule %s", sched.ScheduleID)
+	return respond(c, sched)
+}
+
+func (s *Service) DeleteSchedule(c echo.Context) error {
+	id := c.Param("schedule_id")
+
+	if err := s.Schedules.Delete(id, scheduleIfMatch(c)); err != nil {
+		return scheduleError(c, err)
+	}
+
+	log.Log.Infof("deleted schedule %s", id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Service) GetScheduleRuns(c echo.Context) error {
+	runs, err := s.Schedules.Runs(c.Param("schedule_id"), c.QueryParam("av_room_id"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, runs)
+}
+
+// scheduleIfMatch requires the request's If-Match to have the schedule's
+// current tag, which is the one GetScheduleByID responds with
+func scheduleIfMatch(c echo.Context) func(models.Schedule) error {
+	return func(sched models.Schedule) error {
+		tag, err := bodyETag(sched)
+		if err != nil {
+			return err
+		}
+
+		return precondition(c, tag)
+	}
+}
+
+func scheduleError(c echo.Context, err error) error {
+	var invalid *schedules.InvalidError
+	switch {
+	case errors.Is(err, schedules.ErrNotFound):
+		return c.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, errPreconditionRequired), errors.Is(err, errPreconditionFailed):
+		return preconditionError(c, err)
+	case errors.As(err, &invalid):
+		return c.JSON(http.StatusBadRequest, invalidResponse{
+			Error: invalid.Error(),
+			Details: openapi.ValidationErrors{
+				{In: "body", Name: invalid.Field, Reason: invalid.Reason},
+			},
+		})
+	default:
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+
+	"github.com/labstack/echo"
+)
+
+// defaultUsageRange is how far back usage goes when from isn't given
+const defaultUsageRange = 7 * 24 * time.Hour
+
+//Usage
+
+func (s *Service) GetRoomUsage(c echo.Context) error {
+	roomId := c.Param("room_id")
+
+	from, to, ok, err := parseTimeRange(c, defaultUsageRange)
+	if !ok {
+		return err
+	}
+
+	if !s.History.Recording(roomId) {
+		return c.String(http.StatusNotFound, "State history isn't recorded for the room: "+roomId)
+	}
+
+	usage := s.History.RoomUsage(roomId, from, to, time.Local)
+
+	log.Log.Infof("successfully retrieved usage of %d displays", len(usage.Displays))
+	if c.QueryParam("format") == "csv" {
+		return usageCSV(c, roomId, []models.RoomUsage{usage})
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+func (s *Service) GetBuildingUsage(c echo.Context) error {
+	bldg := c.Param("building_abbreviation")
+
+	from, to, ok, err := parseTimeRange(c, defaultUsageRange)
+	if !ok {
+		return err
+	}
+
+	if !s.History.RecordingBuilding(bldg) {
+		return c.String(http.StatusNotFound, "State history isn't recorded for the building: "+bldg)
+	}
+
+	usage := s.History.BuildingUsage(bldg, from, to, time.Local)
+
+	log.Log.Infof("successfully retrieved usage of %d rooms", len(usage.Rooms))
+	if c.QueryParam("format") == "csv" {
+		return usageCSV(c, bldg, usage.Rooms)
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// usageCSV responds with a row for each display in the rooms: its powered
+// hours, the input it showed the most, and how long it was on during each hour
+// of the day
+func usageCSV(c echo.Context, name string, rooms []models.RoomUsage) error {
+	header := []string{"av_room_id", "av_display_id", "powered_hours", "most_used_input", "most_used_input_hours"}
+	for h := 0; h < 24; h++ {
+		header = append(header, fmt.Sprintf("hour_%02d", h))
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(header)
+
+	for _, room := range rooms {
+		for _, disp := range room.Displays {
+			row := []string{room.RoomID, disp.DisplayID, formatHours(disp.PoweredHours), "", ""}
+			if len(disp.Inputs) > 0 {
+				row[3], row[4] = disp.Inputs[0].InputID, formatHours(disp.Inputs[0].Hours)
+			}
+
+			for _, h := range disp.HourlyUsage {
+				row = append(row, formatHours(h.PoweredHours))
+			}
+
+			w.Write(row)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-usage.csv"`, name))
+	return c.Blob(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+func formatHours(h float64) string {
+	return strconv.FormatFloat(h, 'f', 2, 64)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/webhooks"
+
+	"github.com/labstack/echo"
+)
+
+//Webhooks
+
+func (s *Service) GetWebhooks(c echo.Context) error {
+	hooks := s.Webhooks.List()
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	log.Log.Infof("successfully retrieved: %d webhooks", len(hooks))
+	return c.JSON(http.StatusOK, hooks)
+}
+
+func (s *Service) CreateWebhook(c echo.Context) error {
+	var in models.WebhookInput
+	if err := c.Bind(&in); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	hook, err := s.Webhooks.Create(in)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	log.Log.Infof("created webhook %s", hook.WebhookID)
+	return c.JSON(http.StatusCreated, hook)
+}
+
+func (s *Service) GetWebhookByID(c echo.Context) error {
+	hook, err := s.Webhooks.Get(c.Param("webhook_id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	hook.Secret = ""
+	return respond(c, hook)
+}
+
+func (s *Service) UpdateWebhook(c echo.Context) error {
+	var in models.WebhookInput
+	if err := c.Bind(&in); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	hook, err := s.Webhooks.Update(c.Param("webhook_id"), in, webhookIfMatch(c))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	log.Log.Infof("updated webhook %s", hook.WebhookID)
+	hook.Secret = ""
+	return respond(c, hook)
+}
+
+func (s *Service) DeleteWebhook(c echo.Context) error {
+	id := c.Param("webhook_id")
+
+	if err := s.Webhooks.Delete(id, webhookIfMatch(c)); err != nil {
+		return webhookError(c, err)
+	}
+
+	log.Log.Infof("deleted webhook %s", id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Service) GetWebhookDeadLetters(c echo.Context) error {
+	dls, err := s.Webhooks.DeadLetters(c.Param("webhook_id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, dls)
+}
+
+func (s *Service) RedeliverWebhookDeadLetter(c echo.Context) error {
+	err := s.Webhooks.Redeliver(c.Param("webhook_id"), c.Param("delivery_id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// webhookIfMatch checks the request's If-Match against the webhook's current
+// tag, which is the one GetWebhookByID responds with
+func webhookIfMatch(c echo.Context) func(models.Webhook) error {
+	return func(hook models.Webhook) error {
+		hook.Secret = ""
+		tag, err := bodyETag(hook)
+		if err != nil {
+			return err
+		}
+
+		return precondition(c, tag)
+	}
+}
+
+// invalidResponse has the same shape as the validator's responses
+type invalidResponse struct {
+	Error   string                   `json:"error"`
+	Details openapi.ValidationErrors `json:"details"`
+}
+
+func webhookError(c echo.Context, err error) error {
+	var invalid *webhooks.InvalidError
+	switch {
+	case errors.Is(err, webhooks.ErrNotFound):
+		return c.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, errPreconditionRequired), errors.Is(err, errPreconditionFailed):
+		return preconditionError(c, err)
+	case errors.As(err, &invalid):
+		return c.JSON(http.StatusBadRequest, invalidResponse{
+			Error: invalid.Error(),
+			Details: openapi.ValidationErrors{
+				{In: "body", Name: invalid.Field, Reason: invalid.Reason},
+			},
+		})
+	default:
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/events"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"github.com/gorilla/websocket"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// wsPingInterval is how often clients are pinged; they are dropped if a pong
+	// doesn't come back within wsPongTimeout
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsWriteTimeout = 10 * time.Second
+)
+
+// Websocket message types
+const (
+	wsSubscribe           = "subscribe"
+	wsUnsubscribe         = "unsubscribe"
+	wsSetDisplayState     = "set_display_state"
+	wsSetAudioOutputState = "set_audio_output_state"
+
+	wsDisplayState     = "display_state"
+	wsAudioOutputState = "audio_output_state"
+	wsAck              = "ack"
+	wsResult           = "result"
+	wsError            = "error"
+)
+
+// Authorizer decides whether a websocket client may act on a single resource.
+// path and method are those of the equivalent HTTP route.
+type Authorizer interface {
+	AuthorizeResource(method, path, resource string) (bool, error)
+}
+
+var resourceIDPattern = regexp.MustCompile(`^[^-]+-[^-]+-[^-]+$`)
+
+var upgrader = websocket.Upgrader{}
+
+// wsRequest is a message sent by the client
+type wsRequest struct {
+	Type string `json:"type"`
+
+	// ID is echoed back in the reply so clients can match them up
+	ID string `json:"id,omitempty"`
+
+	// for subscribe/unsubscribe
+	DisplayIDs     []string `json:"av_display_ids,omitempty"`
+	AudioOutputIDs []string `json:"av_audio_output_ids,omitempty"`
+
+	// for set_display_state/set_audio_output_state
+	DisplayID     string          `json:"av_display_id,omitempty"`
+	AudioOutputID string          `json:"av_audio_output_id,omitempty"`
+	State         json.RawMessage `json:"state,omitempty"`
+}
+
+// wsMessage is a message sent to the client
+type wsMessage struct {
+	Type          string      `json:"type"`
+	ID            string      `json:"id,omitempty"`
+	DisplayID     string      `json:"av_display_id,omitempty"`
+	AudioOutputID string      `json:"av_audio_output_id,omitempty"`
+	State         interface{} `json:"state,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+// wsClient is a single websocket connection and what it is subscribed to
+type wsClient struct {
+	s    *Service
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	displays map[string]bool
+	outputs  map[string]bool
+	rooms    map[string]*events.Subscription
+}
+
+//Websocket
+
+func (s *Service) GetWebsocket(c echo.Context) error {
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// the upgrader has already responded
+		log.Log.Infof("unable to upgrade websocket: %s", err)
+		return nil
+	}
+
+	client := &wsClient{
+		s:        s,
+		conn:     conn,
+		displays: map[string]bool{},
+		outputs:  map[string]bool{},
+		rooms:    map[string]*events.Subscription{},
+	}
+
+	log.Log.Infof("websocket opened from %s", c.RealIP())
+	client.run()
+	log.Log.Infof("websocket closed from %s", c.RealIP())
+
+	return nil
+}
+
+// run reads requests until the connection closes
+func (w *wsClient) run() {
+	defer w.close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go w.ping(done)
+
+	w.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	w.conn.SetPongHandler(func(string) error {
+		return w.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		var req wsRequest
+		if err := w.conn.ReadJSON(&req); err != nil {
+			switch err.(type) {
+			case *json.SyntaxError, *json.UnmarshalTypeError:
+				w.write(wsMessage{Type: wsError, Error: "invalid message: " + err.Error()})
+				continue
+			}
+
+			return
+		}
+
+		switch req.Type {
+		case wsSubscribe:
+			w.subscribe(req)
+		case wsUnsubscribe:
+			w.unsubscribe(req)
+		case wsSetDisplayState:
+			w.setDisplayState(req)
+		case wsSetAudioOutputState:
+			w.setAudioOutputState(req)
+		default:
+			w.write(wsMessage{Type: wsError, ID: req.ID, Error: fmt.Sprintf("unknown message type %q", req.Type)})
+		}
+	}
+}
+
+func (w *wsClient) ping(done chan struct{}) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			w.writeMu.Lock()
+			err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			w.writeMu.Unlock()
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (w *wsClient) close() {
+	w.mu.Lock()
+	for id, sub := range w.rooms {
+		delete(w.rooms, id)
+		sub.Close()
+	}
+	w.mu.Unlock()
+
+	w.conn.Close()
+}
+
+func (w *wsClient) write(msg wsMessage) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	w.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return w.conn.WriteJSON(msg)
+}
+
+// authorize checks that the client may make a request to path for the resource
+func (w *wsClient) authorize(method, path, resource string) error {
+	if !resourceIDPattern.MatchString(resource) {
+		return fmt.Errorf("invalid id %q", resource)
+	}
+
+	if w.s.Authorizer == nil {
+		return nil
+	}
+
+	allowed, err := w.s.Authorizer.AuthorizeResource(method, path, resource)
+	switch {
+	case err != nil:
+		return err
+	case !allowed:
+		return fmt.Errorf("Unauthorized")
+	}
+
+	return nil
+}
+
+func (w *wsClient) subscribe(req wsRequest) {
+	for _, id := range req.DisplayIDs {
+		if err := w.authorize(http.MethodGet, "/displays/:av_display_id/state", id); err != nil {
+			w.write(wsMessage{Type: wsError, ID: req.ID, DisplayID: id, Error: err.Error()})
+			continue
+		}
+
+		state, err := w.s.Services.GetDisplayState(id)
+		if err != nil {
+			w.write(wsMessage{Type: wsError, ID: req.ID, DisplayID: id, Error: err.Error()})
+			continue
+		}
+
+		w.watch(w.displays, id)
+		w.write(wsMessage{Type: wsDisplayState, DisplayID: id, State: state})
+	}
+
+	for _, id := range req.AudioOutputIDs {
+		if err := w.authorize(http.MethodGet, "/audio_outputs/:av_audio_output_id/state", id); err != nil {
+			w.write(wsMessage{Type: wsError, ID: req.ID, AudioOutputID: id, Error: err.Error()})
+			continue
+		}
+
+		state, err := w.s.Services.GetAudioOutputState(id)
+		if err != nil {
+			w.write(wsMessage{Type: wsError, ID: req.ID, AudioOutputID: id, Error: err.Error()})
+			continue
+		}
+
+		w.watch(w.outputs, id)
+		w.write(wsMessage{Type: wsAudioOutputState, AudioOutputID: id, State: state})
+	}
+
+	w.write(wsMessage{Type: wsAck, ID: req.ID})
+}
+
+func (w *wsClient) unsubscribe(req wsRequest) {
+	w.mu.Lock()
+	for _, id := range req.DisplayIDs {
+		delete(w.displays, id)
+	}
+
+	for _, id := range req.AudioOutputIDs {
+		delete(w.outputs, id)
+	}
+
+	// stop listening to rooms nothing is subscribed to anymore
+	for room, sub := range w.rooms {
+		if !w.watchingRoom(room) {
+			delete(w.rooms, room)
+			sub.Close()
+		}
+	}
+	w.mu.Unlock()
+
+	w.write(wsMessage{Type: wsAck, ID: req.ID})
+}
+
+// watch adds id to the set of resources, subscribing to its room if needed
+func (w *wsClient) watch(set map[string]bool, id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	set[id] = true
+
+	room := avid.Room(id)
+	if _, ok := w.rooms[room]; ok {
+		return
+	}
+
+	sub := w.s.Events.Subscribe([]string{room}, 0)
+	w.rooms[room] = sub
+	go w.forward(room, sub)
+}
+
+// watchingRoom must be called with w.mu held
+func (w *wsClient) watchingRoom(room string) bool {
+	for id := range w.displays {
+		if avid.Room(id) == room {
+			return true
+		}
+	}
+
+	for id := range w.outputs {
+		if avid.Room(id) == room {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forward sends the client the events from sub for the resources it is subscribed to
+func (w *wsClient) forward(room string, sub *events.Subscription) {
+	for e := range sub.C {
+		w.mu.Lock()
+		var msg *wsMessage
+		switch data := e.Data.(type) {
+		case models.DisplayEvent:
+			if w.displays[data.DisplayID] {
+				msg = &wsMessage{Type: wsDisplayState, DisplayID: data.DisplayID, State: data.DisplayState}
+			}
+		case models.AudioOutputEvent:
+			if w.outputs[data.OutputID] {
+				msg = &wsMessage{Type: wsAudioOutputState, AudioOutputID: data.OutputID, State: data.AudioOutputState}
+			}
+		}
+		w.mu.Unlock()
+
+		if msg != nil {
+			if err := w.write(*msg); err != nil {
+				return
+			}
+		}
+	}
+
+	// if the subscription is still current, the hub dropped it because the client is too slow
+	w.mu.Lock()
+	dropped := w.rooms[room] == sub
+	w.mu.Unlock()
+
+	if dropped {
+		log.Log.Warnf("closing websocket that fell behind on events for %s", room)
+		w.writeMu.Lock()
+		w.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind"), time.Now().Add(wsWriteTimeout))
+		w.writeMu.Unlock()
+		w.conn.Close()
+	}
+}
+
+func (w *wsClient) setDisplayState(req wsRequest) {
+	id := req.DisplayID
+	if err := w.authorize(http.MethodPut, "/displays/:av_display_id/state", id); err != nil {
+		w.write(wsMessage{Type: wsError, ID: req.ID, DisplayID: id, Error: err.Error()})
+		return
+	}
+
+	var update models.DisplayStateUpdate
+	if err := json.Unmarshal(req.State, &update); err != nil {
+		w.write(wsMessage{Type: wsError, ID: req.ID, DisplayID: id, Error: "invalid state: " + err.Error()})
+		return
+	}
+
+	state, err := w.s.Services.SetDisplayState(id, update)
+	if err != nil {
+		w.write(wsMessage{Type: wsError, ID: req.ID, DisplayID: id, Error: err.Error()})
+		return
+	}
+
+	log.Log.Infof("set display state for %s over websocket", id)
+	w.write(wsMessage{Type: wsResult, ID: req.ID, DisplayID: id, State: state})
+}
+
+func (w *wsClient) setAudioOutputState(req wsRequest) {
+	id := req.AudioOutputID
+	if err := w.authorize(http.MethodPut, "/audio_outputs/:av_audio_output_id/state", id); err != nil {
+		w.write(wsMessage{Type: wsError, ID: req.ID, AudioOutputID: id, Error: err.Error()})
+		return
+	}
+
+	var update models.AudioOutputStateUpdate
+	if err := json.Unmarshal(req.State, &update); err != nil {
+		w.write(wsMessage{Type: wsError, ID: req.ID, AudioOutputID: id, Error: "invalid state: " + err.Error()})
+		return
+	}
+
+	state, err := w.s.Services.SetAudioOutputState(id, update)
+	if err != nil {
+		w.write(wsMessage{Type: wsError, ID: req.ID, AudioOutputID: id, Error: err.Error()})
+		return
+	}
+
+	log.Log.Infof("set audio output state for %s over websocket", id)
+	w.write(wsMessage{Type: wsResult, ID: req.ID, AudioOutputID: id, State: state})
+}
@@ -0,0 +1,306 @@
+// Package health polls the AV API for the state of rooms' devices, keeping
+// track of which ones it can't reach and alerting when one stays unreachable.
+package health
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+const (
+	// TypeUnreachable is sent when a device has been unreachable for longer than the threshold
+	TypeUnreachable = "device.unreachable"
+
+	// TypeRecovered is sent when a device that was alerted on is reachable again
+	TypeRecovered = "device.recovered"
+)
+
+// RoomLister finds the rooms in a building
+type RoomLister interface {
+	GetBuildingRoomIDs(bldgAbbr string) ([]string, error)
+}
+
+// Checker checks whether the AV API can reach each of a room's devices
+type Checker interface {
+	CheckRoomDevices(ctx context.Context, roomID string) (map[string]error, error)
+}
+
+// Publisher sends alerts somewhere besides the log
+type Publisher interface {
+	Publish(e models.WebhookEvent)
+}
+
+// Monitor checks the devices in its buildings every Interval
+type Monitor struct {
+	Devices   Checker
+	Rooms     RoomLister
+	Publisher Publisher
+
+	// Buildings are the buildings whose devices are monitored
+	Buildings []string
+
+	// Interval defaults to a minute
+	Interval time.Duration
+
+	// Threshold is how long a device is unreachable before it is alerted on
+	Threshold time.Duration
+
+	// Timeout is how long checking a room may take before its devices count
+	// as unreachable. It defaults to 10 seconds.
+	Timeout time.Duration
+
+	// Concurrency is how many rooms are checked at once
+	Concurrency int
+
+	mu      sync.Mutex
+	devices map[string]*models.DeviceHealth
+}
+
+// Monitoring reports whether the device's building is monitored
+func (m *Monitor) Monitoring(deviceID string) bool {
+	if m == nil {
+		return false
+	}
+
+	bldg := strings.Split(deviceID, "-")[0]
+	for _, b := range m.Buildings {
+		if b == bldg {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Start checks the devices right away, then every Interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			m.Check()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Health returns the device's health, or nil if it hasn't been checked
+func (m *Monitor) Health(deviceID string) *models.DeviceHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.devices[deviceID]
+	if !ok {
+		return nil
+	}
+
+	dev := *h
+	return &dev
+}
+
+// Summary counts the checked devices by health
+func (m *Monitor) Summary() models.HealthSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sum := models.HealthSummary{
+		BldgAbbrs:          m.Buildings,
+		Devices:            len(m.devices),
+		UnreachableDevices: []models.DeviceHealth{},
+	}
+
+	for _, h := range m.devices {
+		if h.Reachable {
+			sum.Reachable++
+			continue
+		}
+
+		sum.Unreachable++
+		if h.Alerting {
+			sum.Alerting++
+		}
+
+		sum.UnreachableDevices = append(sum.UnreachableDevices, *h)
+	}
+
+	sort.Slice(sum.UnreachableDevices, func(i, j int) bool {
+		return sum.UnreachableDevices[i].DeviceID < sum.UnreachableDevices[j].DeviceID
+	})
+
+	return sum
+}
+
+// Check checks every room in the monitored buildings once
+func (m *Monitor) Check() {
+	// rooms that were found, so devices in rooms that no longer exist can be dropped
+	listed := map[string]bool{}
+	found := map[string]bool{}
+
+	var roomIDs []string
+	for _, bldg := range m.Buildings {
+		ids, err := m.Rooms.GetBuildingRoomIDs(bldg)
+		if err != nil {
+			log.Log.Warn("unable to find rooms to check", zap.String("building", bldg), zap.Error(err))
+			continue
+		}
+
+		listed[bldg] = true
+		for _, id := range ids {
+			found[id] = true
+		}
+
+		roomIDs = append(roomIDs, ids...)
+	}
+
+	concurrency := m.Concurrency
+	if concurrency < 1 {
+		concurrency = 8
+	}
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for _, roomID := range roomIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(roomID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			devices, err := m.Devices.CheckRoomDevices(ctx, roomID)
+			if err != nil {
+				// without the room's configuration its devices aren't known, so they're left as they were
+				log.Log.Warn("unable to check room devices", zap.String("room", roomID), zap.Error(err))
+				return
+			}
+
+			m.update(roomID, devices, time.Now().UTC())
+		}(roomID)
+	}
+
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, h := range m.devices {
+		bldg := strings.Split(h.RoomID, "-")[0]
+		if !m.Monitoring(id) || (listed[bldg] && !found[h.RoomID]) {
+			delete(m.devices, id)
+		}
+	}
+}
+
+// update records the result of checking a room's devices
+func (m *Monitor) update(roomID string, devices map[string]error, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.devices == nil {
+		m.devices = map[string]*models.DeviceHealth{}
+	}
+
+	// devices no longer in the room's configuration aren't checked anymore
+	for id, h := range m.devices {
+		if _, ok := devices[id]; !ok && h.RoomID == roomID {
+			delete(m.devices, id)
+		}
+	}
+
+	for id, err := range devices {
+		h, ok := m.devices[id]
+		if !ok {
+			h = &models.DeviceHealth{DeviceID: id, RoomID: roomID}
+			m.devices[id] = h
+		}
+
+		h.LastChecked = now
+
+		if err == nil {
+			recovered := h.Alerting
+
+			seen := now
+			h.Reachable = true
+			h.LastSeen = &seen
+			h.UnreachableSince = nil
+			h.ConsecutiveFailures = 0
+			h.LastError = ""
+			h.Alerting = false
+
+			if recovered {
+				log.Log.Infow("device is reachable again", zap.String("device", id))
+				m.alert(TypeRecovered, *h, now)
+			}
+
+			continue
+		}
+
+		h.Reachable = false
+		h.ConsecutiveFailures++
+		h.LastError = err.Error()
+		if h.UnreachableSince == nil {
+			since := now
+			h.UnreachableSince = &since
+		}
+
+		if !h.Alerting && now.Sub(*h.UnreachableSince) >= m.Threshold {
+			h.Alerting = true
+
+			log.Log.Warnw("device is unreachable",
+				zap.String("device", id),
+				zap.Time("since", *h.UnreachableSince),
+				zap.Int("consecutive_failures", h.ConsecutiveFailures),
+				zap.String("error", h.LastError))
+			m.alert(TypeUnreachable, *h, now)
+		}
+	}
+}
+
+// alert publishes a change in a device's reachability. Its delivery happens
+// in the background, so it is safe to call with m.mu held.
+func (m *Monitor) alert(typ string, h models.DeviceHealth, now time.Time) {
+	if m.Publisher == nil {
+		return
+	}
+
+	m.Publisher.Publish(models.WebhookEvent{
+		Type:         typ,
+		Time:         now,
+		BldgAbbr:     strings.Split(h.RoomID, "-")[0],
+		RoomID:       h.RoomID,
+		ResourceType: "device",
+		ResourceID:   h.DeviceID,
+		Attributes:   []string{"reachable"},
+		Previous:     map[string]interface{}{"reachable": !h.Reachable},
+		Current:      map[string]interface{}{"reachable": h.Reachable},
+		Health:       &h,
+	})
+}
@@ -0,0 +1,229 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/events"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// roomRefreshInterval is how often the buildings' rooms are looked up again, to pick up new rooms
+const roomRefreshInterval = 10 * time.Minute
+
+// pruneInterval is how often records older than the store's retention are dropped
+const pruneInterval = 24 * time.Hour
+
+// RoomLister finds the rooms in a building
+type RoomLister interface {
+	GetBuildingRoomIDs(bldgAbbr string) ([]string, error)
+}
+
+// Recorder records every state change the hub sees in its rooms
+type Recorder struct {
+	Store *Store
+	Hub   *events.Hub
+	Rooms RoomLister
+
+	// Buildings and RoomIDs are the rooms that are recorded
+	Buildings []string
+	RoomIDs   []string
+}
+
+// Recording reports whether the room's state is recorded
+func (r *Recorder) Recording(roomID string) bool {
+	if r == nil {
+		return false
+	}
+
+	return avid.Contains(r.RoomIDs, roomID) || avid.Contains(r.Buildings, avid.Building(roomID))
+}
+
+// RecordingBuilding reports whether any of the building's rooms have their state recorded
+func (r *Recorder) RecordingBuilding(bldgAbbr string) bool {
+	if r == nil {
+		return false
+	}
+
+	if avid.Contains(r.Buildings, bldgAbbr) {
+		return true
+	}
+
+	for _, id := range r.RoomIDs {
+		if strings.HasPrefix(id, bldgAbbr+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Start records state changes until ctx is done
+func (r *Recorder) Start(ctx context.Context) {
+	go r.record(ctx)
+}
+
+// DisplayHistory returns the display's states between from and to
+func (r *Recorder) DisplayHistory(id string, from, to time.Time) models.DisplayStateHistory {
+	h := models.DisplayStateHistory{
+		DisplayID: id,
+		From:      from,
+		To:        to,
+		States:    []models.DisplayStateRecord{},
+	}
+
+	for _, rec := range r.Store.Query(id, from, to) {
+		if rec.Display != nil {
+			h.States = append(h.States, models.DisplayStateRecord{Time: rec.Time, DisplayState: *rec.Display})
+		}
+	}
+
+	return h
+}
+
+// AudioOutputHistory returns the audio output's states between from and to
+func (r *Recorder) AudioOutputHistory(id string, from, to time.Time) models.AudioOutputStateHistory {
+	h := models.AudioOutputStateHistory{
+		OutputID: id,
+		From:     from,
+		To:       to,
+		States:   []models.AudioOutputStateRecord{},
+	}
+
+	for _, rec := range r.Store.Query(id, from, to) {
+		if rec.AudioOutput != nil {
+			h.States = append(h.States, models.AudioOutputStateRecord{Time: rec.Time, AudioOutputState: *rec.AudioOutput})
+		}
+	}
+
+	return h
+}
+
+// record subscribes to the state of the recorded rooms, adding each event to the store
+func (r *Recorder) record(ctx context.Context) {
+	var sub *events.Subscription
+	var rooms []string
+	var lastID uint64
+
+	refresh := time.NewTicker(roomRefreshInterval)
+	defer refresh.Stop()
+
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	defer func() {
+		if sub != nil {
+			sub.Close()
+		}
+	}()
+
+	resubscribe := func() {
+		next := r.rooms()
+		if sub != nil && avid.Equal(next, rooms) {
+			return
+		}
+
+		if sub != nil {
+			sub.Close()
+			sub = nil
+		}
+
+		rooms = next
+		if len(rooms) == 0 {
+			return
+		}
+
+		log.Log.Info("recording room state", zap.Int("rooms", len(rooms)))
+		sub = r.Hub.Subscribe(rooms, lastID)
+		for _, e := range sub.Backlog {
+			lastID = e.ID
+			r.add(e)
+		}
+	}
+
+	resubscribe()
+
+	for {
+		var c <-chan events.Event
+		if sub != nil {
+			c = sub.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh.C:
+			resubscribe()
+		case <-prune.C:
+			if err := r.Store.Prune(); err != nil {
+				log.Log.Error("unable to prune state history", zap.Error(err))
+			}
+		case e, ok := <-c:
+			if !ok {
+				// the hub dropped us for falling behind
+				sub = nil
+				resubscribe()
+				continue
+			}
+
+			lastID = e.ID
+			r.add(e)
+		}
+	}
+}
+
+// rooms returns the recorded rooms, sorted. Buildings whose rooms can't be found are skipped until the next refresh.
+func (r *Recorder) rooms() []string {
+	set := map[string]bool{}
+	for _, id := range r.RoomIDs {
+		set[id] = true
+	}
+
+	for _, bldg := range r.Buildings {
+		ids, err := r.Rooms.GetBuildingRoomIDs(bldg)
+		if err != nil {
+			log.Log.Warn("unable to find rooms to record", zap.String("building", bldg), zap.Error(err))
+			continue
+		}
+
+		for _, id := range ids {
+			set[id] = true
+		}
+	}
+
+	rooms := make([]string, 0, len(set))
+	for id := range set {
+		rooms = append(rooms, id)
+	}
+
+	sort.Strings(rooms)
+	return rooms
+}
+
+// add adds a state event to the store
+func (r *Recorder) add(e events.Event) {
+	rec := Record{
+		Time:       e.Time.UTC(),
+		RoomID:     e.RoomID,
+		ResourceID: e.ResourceID,
+	}
+
+	switch data := e.Data.(type) {
+	case models.DisplayEvent:
+		rec.Display = &data.DisplayState
+	case models.AudioOutputEvent:
+		rec.AudioOutput = &data.AudioOutputState
+	default:
+		return
+	}
+
+	if err := r.Store.Add(rec); err != nil {
+		log.Log.Error("unable to record state", zap.String("id", e.ResourceID), zap.Error(err))
+	}
+}
@@ -0,0 +1,358 @@
+// Package history records the state of displays and audio outputs over time,
+// so that questions like how long a projector was on can be answered.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// Record is the state a display or audio output changed to, and when. Only
+// one of Display and AudioOutput is set.
+type Record struct {
+	Time        time.Time                `json:"time"`
+	RoomID      string                   `json:"room"`
+	ResourceID  string                   `json:"id"`
+	Display     *models.DisplayState     `json:"display,omitempty"`
+	AudioOutput *models.AudioOutputState `json:"audio_output,omitempty"`
+}
+
+// Store keeps each resource's records, oldest first. With a Path they are
+// appended to a file of JSON lines so they survive restarts, and only an index
+// of where they are in it is kept in memory. Without one the records
+// themselves are kept in memory.
+type Store struct {
+	Path string
+
+	// Retention is how long records are kept. If it is zero they are kept forever.
+	Retention time.Duration
+
+	mu      sync.Mutex
+	entries map[string][]entry
+	file    *os.File
+
+	// last is each resource's newest record, which new ones are compared to
+	last map[string]Record
+
+	// size is the length of the file, and live how much of it is records that haven't been pruned
+	size, live int64
+}
+
+// entry is where a record is in the file, or the record itself if there isn't a file
+type entry struct {
+	time time.Time
+	off  int64
+	len  int
+	rec  *Record
+}
+
+// Load indexes the records saved at Path, dropping any older than Retention,
+// and opens it to append new ones
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = map[string][]entry{}
+	s.last = map[string]Record{}
+	s.size, s.live = 0, 0
+	if s.Path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("history/Load open: %w", err)
+	}
+	s.file = f
+
+	// a crash while appending can leave a partial line at the end, which
+	// has to be rewritten before anything else is appended
+	partial := false
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			off := s.size
+			s.size += int64(len(line))
+			partial = line[len(line)-1] != '\n'
+
+			var r Record
+			if err := json.Unmarshal(line, &r); err != nil || partial {
+				log.Log.Warn("skipping unreadable history record", zap.Error(err))
+			} else {
+				s.entries[r.ResourceID] = append(s.entries[r.ResourceID], entry{time: r.Time, off: off, len: len(line)})
+				s.live += int64(len(line))
+
+				if last, ok := s.last[r.ResourceID]; !ok || !r.Time.Before(last.Time) {
+					s.last[r.ResourceID] = r
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("history/Load read: %w", err)
+		}
+	}
+
+	for id := range s.entries {
+		es := s.entries[id]
+		sort.SliceStable(es, func(i, j int) bool { return es[i].time.Before(es[j].time) })
+	}
+
+	return s.compact(time.Now(), partial)
+}
+
+// Add records a resource's state, unless it is the same as the last state recorded for it
+func (s *Store) Add(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries == nil {
+		s.entries = map[string][]entry{}
+		s.last = map[string]Record{}
+	}
+
+	if last, ok := s.last[r.ResourceID]; ok {
+		if reflect.DeepEqual(last.Display, r.Display) && reflect.DeepEqual(last.AudioOutput, r.AudioOutput) {
+			return nil
+		}
+
+		// records are kept in order even if the clock goes backwards
+		if r.Time.Before(last.Time) {
+			r.Time = last.Time
+		}
+	}
+
+	e := entry{time: r.Time}
+	if s.file == nil {
+		rec := r
+		e.rec = &rec
+	} else {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("history/Add marshal: %w", err)
+		}
+
+		n, err := s.file.Write(append(b, '\n'))
+		e.off, e.len = s.size, n
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("history/Add write: %w", err)
+		}
+
+		s.live += int64(n)
+	}
+
+	s.entries[r.ResourceID] = append(s.entries[r.ResourceID], e)
+	s.last[r.ResourceID] = r
+
+	return nil
+}
+
+// Query returns the resource's records between from and to, starting with the
+// state it was in at from
+func (s *Store) Query(resourceID string, from, to time.Time) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	es := s.entries[resourceID]
+
+	// the first record after from
+	i := sort.Search(len(es), func(i int) bool { return es[i].time.After(from) })
+	if i > 0 {
+		i--
+	}
+
+	var result []Record
+	for ; i < len(es) && es[i].time.Before(to); i++ {
+		r, err := s.read(es[i])
+		if err != nil {
+			log.Log.Warn("unable to read history record", zap.String("id", resourceID), zap.Error(err))
+			continue
+		}
+
+		result = append(result, r)
+	}
+
+	return result
+}
+
+// RoomResources returns the id of each resource with state recorded in the room, sorted
+func (s *Store) RoomResources(roomID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for id, r := range s.last {
+		if r.RoomID == roomID {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// BuildingRooms returns the id of each room in the building with recorded state, sorted
+func (s *Store) BuildingRooms(bldgAbbr string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := map[string]bool{}
+	for _, r := range s.last {
+		if strings.HasPrefix(r.RoomID, bldgAbbr+"-") {
+			set[r.RoomID] = true
+		}
+	}
+
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// Prune drops records older than Retention, keeping the last one before it so
+// the state at the start of the retention window is still known
+func (s *Store) Prune() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.compact(time.Now(), false)
+}
+
+// Close closes the file records are appended to
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// read must be called with s.mu held. It returns the record at e.
+func (s *Store) read(e entry) (Record, error) {
+	if e.rec != nil {
+		return *e.rec, nil
+	}
+
+	if s.file == nil {
+		return Record{}, fmt.Errorf("history/read: the file is closed")
+	}
+
+	b := make([]byte, e.len)
+	if _, err := s.file.ReadAt(b, e.off); err != nil {
+		return Record{}, fmt.Errorf("history/read: %w", err)
+	}
+
+	var r Record
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Record{}, fmt.Errorf("history/read unmarshal: %w", err)
+	}
+
+	return r, nil
+}
+
+// compact must be called with s.mu held. It prunes old records from the
+// index, and rewrites the file with the ones that are left if rewrite is set
+// or at least half of it has been pruned. Waiting for that means each record
+// is only copied a few times, however often records are pruned.
+func (s *Store) compact(now time.Time, rewrite bool) error {
+	if s.Retention > 0 {
+		cutoff := now.Add(-s.Retention)
+		for id, es := range s.entries {
+			i := sort.Search(len(es), func(i int) bool { return es[i].time.After(cutoff) })
+			if i > 1 {
+				for _, e := range es[:i-1] {
+					s.live -= int64(e.len)
+				}
+
+				s.entries[id] = append([]entry{}, es[i-1:]...)
+			}
+		}
+	}
+
+	if s.file == nil || (!rewrite && s.size-s.live <= s.live) {
+		return nil
+	}
+
+	// write then rename so a crash doesn't leave a partial file
+	tmp := s.Path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("history/compact create: %w", err)
+	}
+
+	entries := make(map[string][]entry, len(s.entries))
+	var size int64
+
+	w := bufio.NewWriter(f)
+	for id, es := range s.entries {
+		moved := make([]entry, 0, len(es))
+		for _, e := range es {
+			b := make([]byte, e.len)
+			if _, err := s.file.ReadAt(b, e.off); err != nil {
+				f.Close()
+				return fmt.Errorf("history/compact read: %w", err)
+			}
+
+			if _, err := w.Write(b); err != nil {
+				f.Close()
+				return fmt.Errorf("history/compact write: %w", err)
+			}
+
+			moved = append(moved, entry{time: e.time, off: size, len: e.len})
+			size += int64(e.len)
+		}
+
+		entries[id] = moved
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("history/compact write: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("history/compact close: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return fmt.Errorf("history/compact rename: %w", err)
+	}
+
+	s.file.Close()
+	s.entries, s.size, s.live = entries, size, size
+
+	s.file, err = os.OpenFile(s.Path, os.O_APPEND|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("history/compact open: %w", err)
+	}
+
+	return nil
+}
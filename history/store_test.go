@@ -0,0 +1,225 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+func TestStoreCompactsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s := &Store{Path: path, Retention: time.Hour}
+	if err := s.Load(); err != nil {
+		t.Fatalf("unable to load: %s", err)
+	}
+	defer s.Close()
+
+	// five records older than the last one before the retention window, which
+	// are more than half the file once they're pruned
+	for i, ago := range []time.Duration{6 * time.Hour, 5 * time.Hour, 4 * time.Hour, 3 * time.Hour, 2 * time.Hour, 90 * time.Minute, 30 * time.Minute, 10 * time.Minute} {
+		addDisplay(t, s, "ITB-1101-D1", now.Add(-ago), fmt.Sprintf("HDMI%d", i+1))
+	}
+	addDisplay(t, s, "ITB-1101-D2", now.Add(-10*time.Minute), "HDMI1")
+
+	s.mu.Lock()
+	err := s.compact(now, false)
+	s.mu.Unlock()
+	if err != nil {
+		t.Fatalf("unable to compact: %s", err)
+	}
+
+	if s.size != s.live {
+		t.Errorf("file wasn't rewritten: size %d, live %d", s.size, s.live)
+	}
+
+	checkOffsets(t, s, 4)
+
+	// the state at the start of the window is the record from before it
+	want := []string{"HDMI6", "HDMI7", "HDMI8"}
+	if got := displayInputs(s.Query("ITB-1101-D1", now.Add(-time.Hour), now)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got inputs %v, want %v", got, want)
+	}
+
+	// records are appended after the rewritten ones
+	addDisplay(t, s, "ITB-1101-D1", now.Add(-5*time.Minute), "HDMI9")
+	checkOffsets(t, s, 5)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("unable to close: %s", err)
+	}
+
+	reloaded := &Store{Path: path, Retention: time.Hour}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("unable to reload: %s", err)
+	}
+	defer reloaded.Close()
+
+	checkOffsets(t, reloaded, 5)
+
+	want = []string{"HDMI6", "HDMI7", "HDMI8", "HDMI9"}
+	if got := displayInputs(reloaded.Query("ITB-1101-D1", now.Add(-time.Hour), now)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got reloaded inputs %v, want %v", got, want)
+	}
+
+	if got := reloaded.RoomResources("ITB-1101"); !reflect.DeepEqual(got, []string{"ITB-1101-D1", "ITB-1101-D2"}) {
+		t.Errorf("got resources %v", got)
+	}
+}
+
+func TestStoreKeepsFileUntilHalfIsPruned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s := &Store{Path: path, Retention: time.Hour}
+	if err := s.Load(); err != nil {
+		t.Fatalf("unable to load: %s", err)
+	}
+	defer s.Close()
+
+	for i, ago := range []time.Duration{3 * time.Hour, 2 * time.Hour, 30 * time.Minute, 20 * time.Minute, 10 * time.Minute} {
+		addDisplay(t, s, "ITB-1101-D1", now.Add(-ago), fmt.Sprintf("HDMI%d", i+1))
+	}
+
+	size := s.size
+
+	s.mu.Lock()
+	err := s.compact(now, false)
+	s.mu.Unlock()
+	if err != nil {
+		t.Fatalf("unable to compact: %s", err)
+	}
+
+	if s.size != size || s.live >= size {
+		t.Errorf("got size %d and live %d, want size %d with one record pruned", s.size, s.live, size)
+	}
+
+	want := []string{"HDMI2", "HDMI3", "HDMI4", "HDMI5"}
+	if got := displayInputs(s.Query("ITB-1101-D1", now.Add(-time.Hour), now)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got inputs %v, want %v", got, want)
+	}
+}
+
+func TestStoreLoadDropsPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	b, err := json.Marshal(Record{Time: now.Add(-time.Minute), RoomID: "ITB-1101", ResourceID: "ITB-1101-D1", Display: &models.DisplayState{Input: "HDMI1"}})
+	if err != nil {
+		t.Fatalf("unable to marshal: %s", err)
+	}
+
+	if err := os.WriteFile(path, append(append(b, '\n'), b[:len(b)/2]...), 0600); err != nil {
+		t.Fatalf("unable to write: %s", err)
+	}
+
+	s := &Store{Path: path}
+	if err := s.Load(); err != nil {
+		t.Fatalf("unable to load: %s", err)
+	}
+	defer s.Close()
+
+	addDisplay(t, s, "ITB-1101-D1", now, "HDMI2")
+	checkOffsets(t, s, 2)
+
+	want := []string{"HDMI1", "HDMI2"}
+	if got := displayInputs(s.Query("ITB-1101-D1", now.Add(-time.Hour), now.Add(time.Minute))); !reflect.DeepEqual(got, want) {
+		t.Errorf("got inputs %v, want %v", got, want)
+	}
+}
+
+func TestStoreAddSkipsUnchangedState(t *testing.T) {
+	s := &Store{}
+	if err := s.Load(); err != nil {
+		t.Fatalf("unable to load: %s", err)
+	}
+
+	now := time.Now().UTC()
+	addDisplay(t, s, "ITB-1101-D1", now.Add(-2*time.Minute), "HDMI1")
+	addDisplay(t, s, "ITB-1101-D1", now.Add(-time.Minute), "HDMI1")
+	addDisplay(t, s, "ITB-1101-D1", now, "HDMI2")
+
+	want := []string{"HDMI1", "HDMI2"}
+	if got := displayInputs(s.Query("ITB-1101-D1", now.Add(-time.Hour), now.Add(time.Minute))); !reflect.DeepEqual(got, want) {
+		t.Errorf("got inputs %v, want %v", got, want)
+	}
+}
+
+func addDisplay(t *testing.T, s *Store, id string, at time.Time, input string) {
+	t.Helper()
+
+	r := Record{Time: at, RoomID: "ITB-1101", ResourceID: id, Display: &models.DisplayState{Powered: true, Input: input}}
+	if err := s.Add(r); err != nil {
+		t.Fatalf("unable to add %s: %s", id, err)
+	}
+}
+
+func displayInputs(recs []Record) []string {
+	var inputs []string
+	for _, r := range recs {
+		inputs = append(inputs, r.Display.Input)
+	}
+
+	return inputs
+}
+
+// checkOffsets checks that the store's index covers the file line by line,
+// with n records in it
+func checkOffsets(t *testing.T, s *Store, n int) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var es []entry
+	for _, e := range s.entries {
+		es = append(es, e...)
+	}
+
+	sort.Slice(es, func(i, j int) bool { return es[i].off < es[j].off })
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		t.Fatalf("unable to open: %s", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if len(es) != n || len(lines) != n {
+		t.Fatalf("got %d entries and %d lines, want %d", len(es), len(lines), n)
+	}
+
+	var off int64
+	for i, e := range es {
+		if e.off != off || e.len != len(lines[i])+1 {
+			t.Errorf("entry %d is at %d+%d, want %d+%d", i, e.off, e.len, off, len(lines[i])+1)
+		}
+
+		r, err := s.read(e)
+		if err != nil {
+			t.Errorf("unable to read entry %d: %s", i, err)
+		} else if !r.Time.Equal(e.time) {
+			t.Errorf("entry %d is at %s, but its record is at %s", i, e.time, r.Time)
+		}
+
+		off += int64(e.len)
+	}
+
+	if off != s.size {
+		t.Errorf("entries end at %d, but the size is %d", off, s.size)
+	}
+}
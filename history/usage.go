@@ -0,0 +1,200 @@
+package history
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// peakHours is how many of the busiest hours of the day are reported
+const peakHours = 3
+
+// usage adds up how long displays were on
+type usage struct {
+	powered time.Duration
+	inputs  map[string]time.Duration
+	hours   [24]time.Duration
+}
+
+func newUsage() *usage {
+	return &usage{inputs: map[string]time.Duration{}}
+}
+
+// RoomUsage returns how long each of the room's displays was on between from
+// and to, and showing which inputs. Hours of the day are in loc.
+func (r *Recorder) RoomUsage(roomID string, from, to time.Time, loc *time.Location) models.RoomUsage {
+	room, _ := r.roomUsage(roomID, from, to, loc)
+	return room
+}
+
+// roomUsage returns the room's usage, and the totals it is made from
+func (r *Recorder) roomUsage(roomID string, from, to time.Time, loc *time.Location) (models.RoomUsage, *usage) {
+	// state after now hasn't happened yet
+	end := to
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+
+	room := newUsage()
+	displays := []models.DisplayUsage{}
+	for _, id := range r.Store.RoomResources(roomID) {
+		u := newUsage()
+		recorded := false
+
+		records := r.Store.Query(id, from, end)
+		for i, rec := range records {
+			if rec.Display == nil {
+				continue
+			}
+			recorded = true
+
+			if !rec.Display.Powered {
+				continue
+			}
+
+			start, stop := rec.Time, end
+			if start.Before(from) {
+				start = from
+			}
+
+			if i+1 < len(records) {
+				stop = records[i+1].Time
+			}
+
+			u.add(start, stop, rec.Display.Input, loc)
+			room.add(start, stop, rec.Display.Input, loc)
+		}
+
+		if !recorded {
+			continue
+		}
+
+		displays = append(displays, models.DisplayUsage{
+			DisplayID:    id,
+			PoweredHours: hours(u.powered),
+			Inputs:       u.inputUsage(),
+			HourlyUsage:  u.hourlyUsage(),
+		})
+	}
+
+	result := models.RoomUsage{
+		RoomID:       roomID,
+		From:         from,
+		To:           to,
+		PoweredHours: hours(room.powered),
+		Inputs:       room.inputUsage(),
+		HourlyUsage:  room.hourlyUsage(),
+		PeakHours:    room.peakHours(),
+		Displays:     displays,
+	}
+
+	return result, room
+}
+
+// BuildingUsage returns the usage of each room in the building with recorded
+// state, and the building's totals
+func (r *Recorder) BuildingUsage(bldgAbbr string, from, to time.Time, loc *time.Location) models.BuildingUsage {
+	bldg := models.BuildingUsage{
+		BldgAbbr: bldgAbbr,
+		From:     from,
+		To:       to,
+		Rooms:    []models.RoomUsage{},
+	}
+
+	total := newUsage()
+	for _, roomID := range r.Store.BuildingRooms(bldgAbbr) {
+		room, u := r.roomUsage(roomID, from, to, loc)
+		bldg.Rooms = append(bldg.Rooms, room)
+
+		total.powered += u.powered
+		for id, d := range u.inputs {
+			total.inputs[id] += d
+		}
+
+		for h := range u.hours {
+			total.hours[h] += u.hours[h]
+		}
+	}
+
+	bldg.PoweredHours = hours(total.powered)
+	bldg.Inputs = total.inputUsage()
+	bldg.HourlyUsage = total.hourlyUsage()
+	bldg.PeakHours = total.peakHours()
+
+	return bldg
+}
+
+// add counts the time between start and stop as on, showing input
+func (u *usage) add(start, stop time.Time, input string, loc *time.Location) {
+	if !stop.After(start) {
+		return
+	}
+
+	u.powered += stop.Sub(start)
+	if input != "" {
+		u.inputs[input] += stop.Sub(start)
+	}
+
+	// split the time across the hours of the day it covers
+	for t := start; t.Before(stop); {
+		local := t.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day(), local.Hour()+1, 0, 0, 0, loc)
+		if next.After(stop) {
+			next = stop
+		}
+
+		u.hours[local.Hour()] += next.Sub(t)
+		t = next
+	}
+}
+
+// inputUsage returns how long each input was shown, most used first
+func (u *usage) inputUsage() []models.InputUsage {
+	inputs := []models.InputUsage{}
+	for id, d := range u.inputs {
+		inputs = append(inputs, models.InputUsage{InputID: id, Hours: hours(d)})
+	}
+
+	sort.Slice(inputs, func(i, j int) bool {
+		if inputs[i].Hours == inputs[j].Hours {
+			return inputs[i].InputID < inputs[j].InputID
+		}
+		return inputs[i].Hours > inputs[j].Hours
+	})
+
+	return inputs
+}
+
+// hourlyUsage returns how long displays were on during each hour of the day
+func (u *usage) hourlyUsage() []models.HourUsage {
+	hrs := make([]models.HourUsage, 24)
+	for h := range hrs {
+		hrs[h] = models.HourUsage{Hour: h, PoweredHours: hours(u.hours[h])}
+	}
+
+	return hrs
+}
+
+// peakHours returns the hours of the day displays were on the most, busiest first
+func (u *usage) peakHours() []int {
+	hrs := make([]int, 0, 24)
+	for h := range u.hours {
+		if u.hours[h] > 0 {
+			hrs = append(hrs, h)
+		}
+	}
+
+	sort.SliceStable(hrs, func(i, j int) bool { return u.hours[hrs[i]] > u.hours[hrs[j]] })
+	if len(hrs) > peakHours {
+		hrs = hrs[:peakHours]
+	}
+
+	return hrs
+}
+
+// hours converts d to hours, rounded to the hundredth
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
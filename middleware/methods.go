@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// CustomMethods supports routes where a custom method follows a path
+// parameter, like /rooms/:room_id/scenes/:scene:apply. echo reads the whole
+// segment as one parameter named "scene:apply", so this renames it to "scene"
+// and strips ":apply" from its value, or responds 404 if the path doesn't end
+// with it. It must be used on the router (not a group) so it runs before the
+// group's middleware reads the parameters.
+func CustomMethods(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var names []string
+		for i, name := range c.ParamNames() {
+			j := strings.Index(name, ":")
+			if j == -1 {
+				continue
+			}
+
+			// the names belong to the route, so they are copied before being changed
+			if names == nil {
+				names = append([]string{}, c.ParamNames()...)
+			}
+
+			// the values belong to the request, so they can be changed in place
+			values := c.ParamValues()
+			method := name[j:]
+			if !strings.HasSuffix(values[i], method) || len(values[i]) == len(method) {
+				return echo.ErrNotFound
+			}
+
+			names[i] = name[:j]
+			values[i] = strings.TrimSuffix(values[i], method)
+		}
+
+		if names != nil {
+			c.SetParamNames(names...)
+		}
+
+		return next(c)
+	}
+}
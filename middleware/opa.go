@@ -3,6 +3,7 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -30,63 +31,28 @@ type opaRequest struct {
 }
 
 type requestData struct {
-	User   string `json:"user"`
-	Path   string `json:"path"`
-	Method string `json:"method"`
+	User     string `json:"user"`
+	Path     string `json:"path"`
+	Method   string `json:"method"`
+	Resource string `json:"resource,omitempty"`
 }
 
+// errOPA is returned when OPA couldn't be asked or gave an unusable answer
+var errOPA = errors.New("Error while contacting authorization server")
+
 func (client *OPAClient) Authorize(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// Prep the request
-		oReq, err := json.Marshal(
-			opaRequest{
-				Input: requestData{
-					User:   "",
-					Path:   c.Path(),
-					Method: c.Request().Method,
-				},
-			},
-		)
-		if err != nil {
-			log.Log.Errorf("Error trying to create request to OPA: %s\n", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Error while contacting authorization server")
-		}
-
-		req, err := http.NewRequest(
-			"POST",
-			fmt.Sprintf("%s/v1/data/uapi", client.URL),
-			bytes.NewReader(oReq),
-		)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", client.Token))
-
-		// Make the request
-		res, err := http.DefaultClient.Do(req)
-		if err != nil {
-			log.Log.Errorf("Error while making request to OPA: %s", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Error while contacting authorization server")
-		}
-		if res.StatusCode != http.StatusOK {
-			log.Log.Errorf("Got back non 200 status from OPA: %d", res.StatusCode)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Error while contacting authorization server")
-		}
-
-		// Read the body
-		body, err := ioutil.ReadAll(res.Body)
+		allowed, err := client.allowed(requestData{
+			User:   "",
+			Path:   c.Path(),
+			Method: c.Request().Method,
+		})
 		if err != nil {
-			log.Log.Errorf("Unable to read body from OPA: %s", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Error while contacting authorization server")
-		}
-
-		// Unmarshal the body
-		oRes := opaResponse{}
-		err = json.Unmarshal(body, &oRes)
-		if err != nil {
-			log.Log.Errorf("Unable to parse body from OPA: %s", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Error while contacting authorization server")
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 
 		// If OPA approved then allow the request, else reject with a 403
-		if oRes.Result.Allow {
+		if allowed {
 			return next(c)
 		} else {
 			return echo.NewHTTPError(http.StatusForbidden, "Unauthorized")
@@ -94,3 +60,70 @@ func (client *OPAClient) Authorize(next echo.HandlerFunc) echo.HandlerFunc {
 
 	}
 }
+
+// AuthorizeResource asks OPA whether a request to path (a route, like
+// /displays/:av_display_id/state) for the given resource id is allowed.
+// It is used to authorize actions that don't come in as their own request,
+// like subscriptions made over a websocket.
+func (client *OPAClient) AuthorizeResource(method, path, resource string) (bool, error) {
+	return client.allowed(requestData{
+		User:     "",
+		Path:     path,
+		Method:   method,
+		Resource: resource,
+	})
+}
+
+func (client *OPAClient) allowed(input requestData) (bool, error) {
+	// Prep the request
+	oReq, err := json.Marshal(
+		opaRequest{
+			Input: input,
+		},
+	)
+	if err != nil {
+		log.Log.Errorf("Error trying to create request to OPA: %s\n", err)
+		return false, errOPA
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("%s/v1/data/uapi", client.URL),
+		bytes.NewReader(oReq),
+	)
+	if err != nil {
+		log.Log.Errorf("Error trying to create request to OPA: %s\n", err)
+		return false, errOPA
+	}
+	req.Header.Set("authorization", fmt.Sprintf("Bearer %s", client.Token))
+
+	// Make the request
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Log.Errorf("Error while making request to OPA: %s", err)
+		return false, errOPA
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Log.Errorf("Got back non 200 status from OPA: %d", res.StatusCode)
+		return false, errOPA
+	}
+
+	// Read the body
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		log.Log.Errorf("Unable to read body from OPA: %s", err)
+		return false, errOPA
+	}
+
+	// Unmarshal the body
+	oRes := opaResponse{}
+	err = json.Unmarshal(body, &oRes)
+	if err != nil {
+		log.Log.Errorf("Unable to parse body from OPA: %s", err)
+		return false, errOPA
+	}
+
+	return oRes.Result.Allow, nil
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/labstack/echo"
+)
+
+// Validator checks requests (and optionally responses) against an OpenAPI spec
+type Validator struct {
+	Spec *openapi.Spec
+
+	// ValidateResponses turns on checking of response bodies. Mismatches are
+	// only logged, so this is meant for debugging drift between the models and the spec.
+	ValidateResponses bool
+}
+
+type validationResponse struct {
+	Error   string                   `json:"error"`
+	Details openapi.ValidationErrors `json:"details"`
+}
+
+func (v *Validator) Validate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		op, params := v.Spec.Operation(c.Request().Method, c.Path())
+		if op == nil {
+			// Routes the spec doesn't describe aren't validated
+			return next(c)
+		}
+
+		errs := openapi.ValidateParameters(params, c.Param, c.QueryParams(), c.Request().Header)
+
+		if op.RequestBody != nil {
+			body, err := ioutil.ReadAll(c.Request().Body)
+			if err != nil {
+				log.Log.Errorf("Unable to read request body: %s", err)
+				return echo.NewHTTPError(http.StatusBadRequest, "Unable to read request body")
+			}
+			c.Request().Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			switch {
+			case len(body) == 0 && op.RequestBody.Required:
+				errs = append(errs, openapi.ValidationError{In: "body", Reason: "is required"})
+			case len(body) > 0:
+				if mt, ok := op.RequestBody.Content[echo.MIMEApplicationJSON]; ok && mt.Schema != nil {
+					errs = append(errs, openapi.ValidateBody(mt.Schema, "body", body)...)
+				}
+			}
+		}
+
+		if missingPrecondition(errs) {
+			log.Log.Infof("Rejecting request to %s without an If-Match header", c.Path())
+			return c.String(http.StatusPreconditionRequired, "an If-Match header is required to change this resource")
+		}
+
+		if len(errs) > 0 {
+			log.Log.Infof("Rejecting invalid request to %s: %s", c.Path(), errs)
+			return c.JSON(http.StatusBadRequest, validationResponse{
+				Error:   "request does not match the API specification",
+				Details: errs,
+			})
+		}
+
+		if !v.ValidateResponses || !respondsWithJSON(op) {
+			return next(c)
+		}
+
+		rec := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = rec
+
+		if err := next(c); err != nil {
+			return err
+		}
+
+		res, ok := op.Responses[strconv.Itoa(c.Response().Status)]
+		if !ok {
+			res, ok = op.Responses["default"]
+		}
+
+		if !ok {
+			log.Log.Warnf("Response status %d from %s is not described by the spec", c.Response().Status, c.Path())
+			return nil
+		}
+
+		if mt, ok := res.Content[echo.MIMEApplicationJSON]; ok && mt.Schema != nil {
+			if errs := openapi.ValidateBody(mt.Schema, "response", rec.body.Bytes()); len(errs) > 0 {
+				log.Log.Errorf("Response from %s (%s) does not match the spec: %s", c.Path(), op.OperationID, errs)
+			}
+		}
+
+		return nil
+	}
+}
+
+// missingPrecondition reports whether the only thing wrong with a request is
+// a missing If-Match, which is answered with 428 rather than 400
+func missingPrecondition(errs openapi.ValidationErrors) bool {
+	if len(errs) == 0 {
+		return false
+	}
+
+	for _, e := range errs {
+		if e.In != "header" || !strings.EqualFold(e.Name, "If-Match") || e.Reason != "is required" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// respondsWithJSON reports whether op's successful response is JSON. Other
+// responses (like event streams) aren't recorded, since they may never end.
+func respondsWithJSON(op *openapi.Operation) bool {
+	res, ok := op.Responses[strconv.Itoa(http.StatusOK)]
+	if !ok {
+		return true
+	}
+
+	_, ok = res.Content[echo.MIMEApplicationJSON]
+	return ok
+}
+
+// responseRecorder keeps a copy of everything written to the response
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
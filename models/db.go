@@ -2,38 +2,6 @@ package models
 
 import "github.com/byuoitav/common/structs"
 
-type CouchSearch struct {
-	GT    string `json:"$gt,omitempty"`
-	LT    string `json:"$lt,omitempty"`
-	Regex string `json:"$regex,omitempty"`
-}
-
-type RoomQuery struct {
-	Selector struct {
-		ID CouchSearch `json:"_id"`
-	} `json:"selector"`
-	Limit int `json:"limit"`
-}
-
-type DeviceTypeQuery struct {
-	ID *CouchSearch `json:"_id,omitempty"`
-}
-
-type DeviceQuery struct {
-	Selector struct {
-		ID      CouchSearch      `json:"_id"`
-		DevType *DeviceTypeQuery `json:"type,omitempty"`
-	} `json:"selector"`
-	Limit int `json:"limit"`
-}
-
-type UIConfigQuery struct {
-	Selector struct {
-		ID CouchSearch `json:"_id"`
-	} `json:"selector"`
-	Limit int `json:"limit"`
-}
-
 // Rooms
 type RoomResponse struct {
 	Docs     []RoomDB `json:"docs"`
@@ -46,17 +14,6 @@ type RoomDB struct {
 	*structs.Room
 }
 
-type DeviceResponse struct {
-	Docs     []DeviceDB `json:"docs"`
-	Bookmark string     `json:"bookmark"`
-	Warning  string     `json:"warning"`
-}
-
-type DeviceDB struct {
-	Rev string `json:"_rev,omitempty"`
-	*structs.Device
-}
-
 type DisplayResponse struct {
 	Docs     []DisplayDB `json:"docs"`
 	Bookmark string      `json:"bookmark"`
@@ -84,6 +41,26 @@ type RoomState struct {
 	AudioDevices []StateAudioDevice `json:"audioDevices,omitempty"`
 }
 
+// RoomStateChange is sent to the AV API to change a room's state.
+// Only the fields that are set are changed.
+type RoomStateChange struct {
+	Displays     []DisplayChange     `json:"displays,omitempty"`
+	AudioDevices []AudioDeviceChange `json:"audioDevices,omitempty"`
+}
+
+type DisplayChange struct {
+	Name    string  `json:"name"`
+	Power   *string `json:"power,omitempty"`
+	Input   *string `json:"input,omitempty"`
+	Blanked *bool   `json:"blanked,omitempty"`
+}
+
+type AudioDeviceChange struct {
+	Name   string `json:"name"`
+	Muted  *bool  `json:"muted,omitempty"`
+	Volume *int   `json:"volume,omitempty"`
+}
+
 type StateDisplay struct {
 	Name    string `json:"name,omitempty"`
 	Power   string `json:"power,omitempty"`
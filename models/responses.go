@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 //Rooms
 type Room struct {
 	RoomID      string     `json:"av_room_id"`
@@ -7,6 +9,10 @@ type Room struct {
 	BldgAbbr    string     `json:"building_abbreviation"`
 	Description string     `json:"av_room_description"`
 	Resources   []Resource `json:"av_resources"`
+
+	// embedded with expand=
+	Devices *RoomDevices       `json:"av_room_devices,omitempty"`
+	State   *RoomResourceState `json:"av_room_state,omitempty"`
 }
 
 type Resource struct {
@@ -21,6 +27,15 @@ type RoomDevices struct {
 	Inputs   []string `json:"av_inputs"`
 }
 
+//Buildings
+type Building struct {
+	BldgAbbr    string     `json:"building_abbreviation"`
+	Name        string     `json:"building_name"`
+	Description string     `json:"building_description"`
+	RoomCount   int        `json:"room_count"`
+	Resources   []Resource `json:"av_resources"`
+}
+
 //Devices
 type Device struct {
 	DeviceID   string `json:"av_device_id"`
@@ -40,9 +55,27 @@ type DeviceStateAttribute struct {
 	Value string `json:"av_device_state_attribute_value"`
 }
 
+//Device Types
+type DeviceType struct {
+	DeviceTypeID string           `json:"av_device_type_id"`
+	Description  string           `json:"av_device_type_description"`
+	Roles        []string         `json:"av_device_type_roles"`
+	Ports        []DeviceTypePort `json:"av_device_type_ports"`
+	Commands     []string         `json:"av_device_type_commands"`
+	DeviceCount  int              `json:"av_device_count"`
+}
+
+type DeviceTypePort struct {
+	PortID       string `json:"av_port_id"`
+	FriendlyName string `json:"av_port_friendly_name,omitempty"`
+	PortType     string `json:"av_port_type,omitempty"`
+}
+
 //Inputs
 type Input struct {
 	DeviceID   string   `json:"av_device_id"`
+	Name       string   `json:"av_input_name"`
+	Icon       string   `json:"av_input_icon,omitempty"`
 	RoomNum    string   `json:"room_number"`
 	BldgAbbr   string   `json:"building_abbreviation"`
 	DeviceType string   `json:"av_device_type"`
@@ -52,13 +85,26 @@ type Input struct {
 //Displays
 type Display struct {
 	DisplayID string `json:"av_display_id"`
+	Name      string `json:"av_display_name"`
+	Icon      string `json:"av_display_icon,omitempty"`
 	RoomNum   string `json:"room_number"`
 	BldgAbbr  string `json:"building_abbreviation"`
+
+	// embedded with expand=
+	Config *DisplayConfig `json:"av_display_config,omitempty"`
+	State  *DisplayState  `json:"av_display_state,omitempty"`
 }
 
 type DisplayConfig struct {
-	Devices []string `json:"av_devices"`
-	Inputs  []string `json:"av_inputs"`
+	Devices []string       `json:"av_devices"`
+	Inputs  []DisplayInput `json:"av_inputs"`
+}
+
+// DisplayInput is an input that can be shown on a display, labeled like it is on the room's touch panels
+type DisplayInput struct {
+	InputID string `json:"av_input_id"`
+	Name    string `json:"av_input_name"`
+	Icon    string `json:"av_input_icon,omitempty"`
 }
 
 type DisplayState struct {
@@ -67,15 +113,294 @@ type DisplayState struct {
 	Input   string `json:"av_display_input"`
 }
 
+// DisplayStateUpdate is a change to a display's state. Fields that aren't set are left alone.
+type DisplayStateUpdate struct {
+	Powered *bool   `json:"av_display_powered,omitempty"`
+	Blanked *bool   `json:"av_display_blanked,omitempty"`
+	Input   *string `json:"av_display_input,omitempty"`
+}
+
 //Audio Outputs
 type AudioOutput struct {
 	OutputID   string `json:"av_audio_output_id"`
 	RoomNum    string `json:"room_number"`
 	BldgAbbr   string `json:"building_abbreviation"`
 	DeviceType string `json:"av_device_type"`
+
+	// embedded with expand=
+	State *AudioOutputState `json:"av_audio_output_state,omitempty"`
 }
 
 type AudioOutputState struct {
 	Volume int  `json:"av_audio_output_volume_level"`
 	Muted  bool `json:"av_audio_output_muted"`
 }
+
+// AudioOutputStateUpdate is a change to an audio output's state. Fields that aren't set are left alone.
+type AudioOutputStateUpdate struct {
+	Volume *int  `json:"av_audio_output_volume_level,omitempty"`
+	Muted  *bool `json:"av_audio_output_muted,omitempty"`
+}
+
+//Microphones
+type Microphone struct {
+	MicrophoneID string `json:"av_microphone_id"`
+	Name         string `json:"av_microphone_name"`
+	RoomNum      string `json:"room_number"`
+	BldgAbbr     string `json:"building_abbreviation"`
+	DeviceType   string `json:"av_device_type"`
+
+	// the ui-configuration audio group the microphone is in, if any
+	AudioGroup string `json:"av_audio_group,omitempty"`
+}
+
+type MicrophoneState struct {
+	Volume int  `json:"av_microphone_volume_level"`
+	Muted  bool `json:"av_microphone_muted"`
+}
+
+// MicrophoneStateUpdate is a change to a microphone's state. Fields that aren't set are left alone.
+type MicrophoneStateUpdate struct {
+	Volume *int  `json:"av_microphone_volume_level"`
+	Muted  *bool `json:"av_microphone_muted"`
+}
+
+//Groups
+
+// DisplayGroup is a display in a divisible room along with the displays that are sharing it
+type DisplayGroup struct {
+	DisplayID string `json:"av_display_id"`
+	Input     string `json:"av_display_input,omitempty"`
+
+	// the displays showing this display's input, not including itself
+	Shared []string `json:"av_shared_display_ids"`
+
+	// the displays that can be shared with this display
+	Shareable []string `json:"av_shareable_display_ids"`
+
+	// the master audio of every display in the group
+	AudioOutputs []string `json:"av_audio_output_ids"`
+}
+
+// DisplayGroupUpdate sets which displays are sharing a display. Displays left out are unshared.
+type DisplayGroupUpdate struct {
+	Shared []string `json:"av_shared_display_ids"`
+}
+
+//Scenes
+
+// Scene is a named set of changes to a room's displays and audio outputs that are applied together
+type Scene struct {
+	Name         string             `json:"av_scene_name"`
+	Description  string             `json:"av_scene_description,omitempty"`
+	Displays     []SceneDisplay     `json:"av_displays"`
+	AudioOutputs []SceneAudioOutput `json:"av_audio_outputs"`
+}
+
+// SceneDisplay is the change a scene makes to a display
+type SceneDisplay struct {
+	DisplayID string  `json:"av_display_id"`
+	Powered   *bool   `json:"av_display_powered,omitempty"`
+	Blanked   *bool   `json:"av_display_blanked,omitempty"`
+	Input     *string `json:"av_display_input,omitempty"`
+}
+
+// SceneAudioOutput is the change a scene makes to an audio output
+type SceneAudioOutput struct {
+	OutputID string `json:"av_audio_output_id"`
+	Volume   *int   `json:"av_audio_output_volume_level,omitempty"`
+	Muted    *bool  `json:"av_audio_output_muted,omitempty"`
+}
+
+//Cameras
+type Camera struct {
+	CameraID string `json:"av_camera_id"`
+	Name     string `json:"av_camera_name"`
+	RoomNum  string `json:"room_number"`
+	BldgAbbr string `json:"building_abbreviation"`
+	Stream   string `json:"av_camera_stream,omitempty"`
+
+	// the names of the positions the camera can be moved to
+	Presets []string `json:"av_camera_presets"`
+
+	// the pan, tilt and zoom commands the camera supports
+	Commands []string `json:"av_camera_commands"`
+}
+
+// CameraControl moves a camera, either to one of its presets or with a pan, tilt or zoom command
+type CameraControl struct {
+	Preset  string `json:"av_camera_preset,omitempty"`
+	Command string `json:"av_camera_command,omitempty"`
+}
+
+//Room State
+type RoomResourceState struct {
+	Displays     map[string]DisplayState     `json:"av_displays"`
+	AudioOutputs map[string]AudioOutputState `json:"av_audio_outputs"`
+}
+
+//Batch State
+// DisplayStateBatchRequest asks for the state of many displays at once
+type DisplayStateBatchRequest struct {
+	DisplayIDs []string `json:"av_display_ids"`
+}
+
+// DisplayStateResult is the state of one display in a batch, or why it couldn't be found
+type DisplayStateResult struct {
+	DisplayID string        `json:"av_display_id"`
+	State     *DisplayState `json:"av_display_state,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AudioOutputStateBatchRequest asks for the state of many audio outputs at once
+type AudioOutputStateBatchRequest struct {
+	OutputIDs []string `json:"av_audio_output_ids"`
+}
+
+// AudioOutputStateResult is the state of one audio output in a batch, or why it couldn't be found
+type AudioOutputStateResult struct {
+	OutputID string            `json:"av_audio_output_id"`
+	State    *AudioOutputState `json:"av_audio_output_state,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+//Events
+type DisplayEvent struct {
+	DisplayID string `json:"av_display_id"`
+	DisplayState
+}
+
+type AudioOutputEvent struct {
+	OutputID string `json:"av_audio_output_id"`
+	AudioOutputState
+}
+
+//History
+
+// DisplayStateHistory is each state a display was in between From and To. The
+// first state is the one it was in at From, so it may have started earlier.
+type DisplayStateHistory struct {
+	DisplayID string               `json:"av_display_id"`
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	States    []DisplayStateRecord `json:"states"`
+}
+
+// DisplayStateRecord is a state a display changed to, and when
+type DisplayStateRecord struct {
+	Time time.Time `json:"time"`
+	DisplayState
+}
+
+// AudioOutputStateHistory is each state an audio output was in between From
+// and To. The first state is the one it was in at From, so it may have started earlier.
+type AudioOutputStateHistory struct {
+	OutputID string                   `json:"av_audio_output_id"`
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	States   []AudioOutputStateRecord `json:"states"`
+}
+
+// AudioOutputStateRecord is a state an audio output changed to, and when
+type AudioOutputStateRecord struct {
+	Time time.Time `json:"time"`
+	AudioOutputState
+}
+
+//Usage
+
+// RoomUsage is how much a room's displays were used between From and To.
+// Hours are display hours, so two displays on for an hour is two hours.
+type RoomUsage struct {
+	RoomID       string         `json:"av_room_id"`
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	PoweredHours float64        `json:"powered_hours"`
+	Inputs       []InputUsage   `json:"inputs"`
+	HourlyUsage  []HourUsage    `json:"hourly_usage"`
+	PeakHours    []int          `json:"peak_hours"`
+	Displays     []DisplayUsage `json:"av_displays"`
+}
+
+// BuildingUsage is how much the displays in a building's recorded rooms were used between From and To
+type BuildingUsage struct {
+	BldgAbbr     string       `json:"building_abbreviation"`
+	From         time.Time    `json:"from"`
+	To           time.Time    `json:"to"`
+	PoweredHours float64      `json:"powered_hours"`
+	Inputs       []InputUsage `json:"inputs"`
+	HourlyUsage  []HourUsage  `json:"hourly_usage"`
+	PeakHours    []int        `json:"peak_hours"`
+	Rooms        []RoomUsage  `json:"rooms"`
+}
+
+// DisplayUsage is how long a display was on, and showing each input
+type DisplayUsage struct {
+	DisplayID    string       `json:"av_display_id"`
+	PoweredHours float64      `json:"powered_hours"`
+	Inputs       []InputUsage `json:"inputs"`
+	HourlyUsage  []HourUsage  `json:"hourly_usage"`
+}
+
+// InputUsage is how long displays were on showing an input
+type InputUsage struct {
+	InputID string  `json:"av_input_id"`
+	Hours   float64 `json:"hours"`
+}
+
+// HourUsage is how long displays were on during an hour of the day, over every day in the range
+type HourUsage struct {
+	Hour         int     `json:"hour"`
+	PoweredHours float64 `json:"powered_hours"`
+}
+
+//Health
+
+// DeviceHealth is whether the AV API could reach a device the last time its room was checked
+type DeviceHealth struct {
+	DeviceID    string     `json:"av_device_id"`
+	RoomID      string     `json:"av_room_id"`
+	Reachable   bool       `json:"reachable"`
+	LastChecked time.Time  `json:"last_checked"`
+	LastSeen    *time.Time `json:"last_seen,omitempty"`
+
+	// UnreachableSince is when the current run of failed checks started
+	UnreachableSince    *time.Time `json:"unreachable_since,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+
+	// Alerting is set once a device has been unreachable for longer than the alert threshold
+	Alerting bool `json:"alerting"`
+}
+
+// HealthSummary counts the monitored devices by health, and lists the unreachable ones
+type HealthSummary struct {
+	BldgAbbrs          []string       `json:"building_abbreviations"`
+	Devices            int            `json:"devices"`
+	Reachable          int            `json:"reachable"`
+	Unreachable        int            `json:"unreachable"`
+	Alerting           int            `json:"alerting"`
+	UnreachableDevices []DeviceHealth `json:"unreachable_devices"`
+}
+
+//Lint
+
+// LintReport is every inconsistency found between rooms' ui-configurations and the devices they name
+type LintReport struct {
+	BldgAbbr     string      `json:"building_abbreviation,omitempty"`
+	RoomsChecked int         `json:"rooms_checked"`
+	Issues       []LintIssue `json:"issues"`
+}
+
+// LintIssue is one inconsistency in a room's configuration
+type LintIssue struct {
+	RoomID string `json:"av_room_id"`
+	Kind   string `json:"kind"`
+
+	// Field is where in the ui-configuration the issue is, like presets[0].displays
+	Field string `json:"field,omitempty"`
+
+	// Reference is the name or device id the issue is about
+	Reference string `json:"reference,omitempty"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+//Schedules
+type Schedule struct {
+	ScheduleID string `json:"schedule_id"`
+	Name       string `json:"name,omitempty"`
+
+	// Cron is when the schedule runs, as minute hour day-of-month month
+	// day-of-week in TimeZone
+	Cron string `json:"cron"`
+
+	// TimeZone is the IANA name of the zone Cron is in, like America/Denver.
+	// If it's empty, Cron is in the translator's time zone.
+	TimeZone string `json:"time_zone,omitempty"`
+
+	Targets ScheduleTargets `json:"targets"`
+	Action  ScheduleAction  `json:"action"`
+	Enabled bool            `json:"enabled"`
+	Created time.Time       `json:"created"`
+	Updated time.Time       `json:"updated"`
+}
+
+// ScheduleTargets are the rooms a schedule changes: every room in the buildings, and the rooms listed
+type ScheduleTargets struct {
+	BldgAbbrs []string `json:"building_abbreviations,omitempty"`
+	RoomIDs   []string `json:"av_room_ids,omitempty"`
+}
+
+// ScheduleAction is the change a schedule makes to every display or audio
+// output in its rooms. Fields that aren't set are left alone.
+type ScheduleAction struct {
+	Display     *DisplayStateUpdate     `json:"av_display_state,omitempty"`
+	AudioOutput *AudioOutputStateUpdate `json:"av_audio_output_state,omitempty"`
+}
+
+type ScheduleInput struct {
+	Name    string          `json:"name,omitempty"`
+	Cron    string          `json:"cron"`
+	Targets ScheduleTargets `json:"targets"`
+	Action  ScheduleAction  `json:"action"`
+
+	// TimeZone defaults to the translator's time zone
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// ScheduleRun is one execution of a schedule
+type ScheduleRun struct {
+	RunID      string    `json:"run_id"`
+	ScheduleID string    `json:"schedule_id"`
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+	Succeeded  int       `json:"succeeded"`
+	Failed     int       `json:"failed"`
+
+	// Error is why the schedule's rooms couldn't be found, if they couldn't
+	Error string `json:"error,omitempty"`
+
+	Rooms []ScheduleRoomResult `json:"rooms"`
+}
+
+// ScheduleRoomResult is whether a schedule's action worked in one of its rooms
+type ScheduleRoomResult struct {
+	RoomID    string `json:"av_room_id"`
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+}
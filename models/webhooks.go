@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+//Webhooks
+type Webhook struct {
+	WebhookID string         `json:"webhook_id"`
+	URL       string         `json:"url"`
+	Filters   WebhookFilters `json:"filters"`
+	Created   time.Time      `json:"created"`
+
+	// Secret signs each delivery. It is only returned when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookFilters limit the events sent to a webhook. Empty filters match everything.
+type WebhookFilters struct {
+	BldgAbbrs     []string `json:"building_abbreviations,omitempty"`
+	RoomIDs       []string `json:"av_room_ids,omitempty"`
+	ResourceTypes []string `json:"resource_types,omitempty"`
+
+	// Attributes match state changes to the named attributes, or
+	// (as name=value) changes of the attribute to the given value
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+type WebhookInput struct {
+	URL     string         `json:"url"`
+	Secret  string         `json:"secret,omitempty"`
+	Filters WebhookFilters `json:"filters"`
+}
+
+// WebhookEvent is the body of a webhook delivery
+type WebhookEvent struct {
+	EventID      string    `json:"event_id"`
+	Type         string    `json:"type"`
+	Time         time.Time `json:"time"`
+	BldgAbbr     string    `json:"building_abbreviation"`
+	RoomID       string    `json:"av_room_id"`
+	ResourceType string    `json:"resource_type"`
+	ResourceID   string    `json:"resource_id"`
+
+	// for state changes
+	Attributes []string               `json:"attributes,omitempty"`
+	Previous   map[string]interface{} `json:"previous,omitempty"`
+	Current    map[string]interface{} `json:"current,omitempty"`
+
+	// for configuration changes
+	Database string `json:"database,omitempty"`
+	Rev      string `json:"rev,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+
+	// for device health alerts
+	Health *DeviceHealth `json:"health,omitempty"`
+}
+
+// DeadLetter is a delivery that failed every attempt
+type DeadLetter struct {
+	DeliveryID string       `json:"delivery_id"`
+	WebhookID  string       `json:"webhook_id"`
+	Event      WebhookEvent `json:"event"`
+	Attempts   int          `json:"attempts"`
+	LastError  string       `json:"last_error"`
+	FailedAt   time.Time    `json:"failed_at"`
+}
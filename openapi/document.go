@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+type server struct {
+	URL string `json:"url"`
+}
+
+// JSON returns the original document as JSON, with its servers block
+// replaced by the given urls
+func (s *Spec) JSON(servers ...string) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(s.raw, &doc); err != nil {
+		return nil, fmt.Errorf("openapi/JSON unmarshal: %w", err)
+	}
+
+	srvs := make([]server, len(servers))
+	for i := range servers {
+		srvs[i].URL = servers[i]
+	}
+	doc["servers"] = srvs
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi/JSON marshal: %w", err)
+	}
+
+	return b, nil
+}
+
+// YAML returns the original document as YAML, with its servers block
+// replaced by the given urls
+func (s *Spec) YAML(servers ...string) ([]byte, error) {
+	b, err := s.JSON(servers...)
+	if err != nil {
+		return nil, fmt.Errorf("openapi/YAML: %w", err)
+	}
+
+	b, err = yaml.JSONToYAML(b)
+	if err != nil {
+		return nil, fmt.Errorf("openapi/YAML convert to yaml: %w", err)
+	}
+
+	return b, nil
+}
+
+// Describes returns true if the spec has an operation for the given
+// method and echo route path
+func (s *Spec) Describes(method, route string) bool {
+	op, _ := s.Operation(method, route)
+	return op != nil
+}
+
+// OperationByID returns the operation with the given operationId along with
+// the method and path it is found at. A nil operation is returned if there isn't one.
+func (s *Spec) OperationByID(id string) (string, string, *Operation) {
+	for path, item := range s.Paths {
+		for method, op := range item.operations() {
+			if op.OperationID == id {
+				return method, path, op
+			}
+		}
+	}
+
+	return "", "", nil
+}
+
+// OperationIDs returns the operationId of every operation in the spec
+func (s *Spec) OperationIDs() []string {
+	var ids []string
+	for _, item := range s.Paths {
+		for _, op := range item.operations() {
+			ids = append(ids, op.OperationID)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,344 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Spec represents the parts of an OpenAPI 3 document that the translator uses
+type Spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	// raw is the original document, converted to JSON
+	raw []byte
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem holds the operations available on a single path
+type PathItem struct {
+	Parameters []Parameter `json:"parameters"`
+	Get        *Operation  `json:"get"`
+	Put        *Operation  `json:"put"`
+	Post       *Operation  `json:"post"`
+	Delete     *Operation  `json:"delete"`
+	Patch      *Operation  `json:"patch"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description"`
+	Parameters  []Parameter         `json:"parameters"`
+	RequestBody *RequestBody        `json:"requestBody"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Explode     *bool   `json:"explode"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON schema that the spec makes use of
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Title                string             `json:"title"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	MinProperties        *int               `json:"minProperties"`
+	Nullable             bool               `json:"nullable"`
+
+	// composition isn't supported by the validator, so a spec using it is rejected
+	// rather than having those schemas silently accept anything
+	AllOf []*Schema `json:"allOf"`
+	OneOf []*Schema `json:"oneOf"`
+	AnyOf []*Schema `json:"anyOf"`
+	Not   *Schema   `json:"not"`
+
+	pattern *regexp.Regexp
+}
+
+// composition returns the first composition keyword the schema uses, or "" if it doesn't use any
+func (s *Schema) composition() string {
+	switch {
+	case s.AllOf != nil:
+		return "allOf"
+	case s.OneOf != nil:
+		return "oneOf"
+	case s.AnyOf != nil:
+		return "anyOf"
+	case s.Not != nil:
+		return "not"
+	}
+
+	return ""
+}
+
+// Load reads and parses the OpenAPI document found at path
+func Load(path string) (*Spec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("openapi/Load read spec: %w", err)
+	}
+
+	return Parse(b)
+}
+
+// Parse parses a YAML or JSON OpenAPI document and resolves every $ref in it
+func Parse(doc []byte) (*Spec, error) {
+	b, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi/Parse convert to json: %w", err)
+	}
+
+	s := &Spec{raw: b}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("openapi/Parse unmarshal: %w", err)
+	}
+
+	if err := s.resolve(); err != nil {
+		return nil, fmt.Errorf("openapi/Parse: %w", err)
+	}
+
+	return s, nil
+}
+
+// Operation returns the operation for the given method and echo route path
+// (e.g. /rooms/:room_id), along with the parameters that apply to it.
+// A nil operation is returned if the spec doesn't describe the route.
+func (s *Spec) Operation(method, route string) (*Operation, []Parameter) {
+	item, ok := s.Paths[EchoToOpenAPI(route)]
+	if !ok {
+		return nil, nil
+	}
+
+	op := item.operation(method)
+	if op == nil {
+		return nil, nil
+	}
+
+	// Operation level parameters override path level ones with the same name and location
+	params := append([]Parameter{}, op.Parameters...)
+	for _, p := range item.Parameters {
+		found := false
+		for _, o := range op.Parameters {
+			if o.Name == p.Name && o.In == p.In {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			params = append(params, p)
+		}
+	}
+
+	return op, params
+}
+
+func (p *PathItem) operation(method string) *Operation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPost:
+		return p.Post
+	case http.MethodDelete:
+		return p.Delete
+	case http.MethodPatch:
+		return p.Patch
+	}
+
+	return nil
+}
+
+// operations returns every operation on the path keyed by method
+func (p *PathItem) operations() map[string]*Operation {
+	ops := map[string]*Operation{}
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch} {
+		if op := p.operation(method); op != nil {
+			ops[method] = op
+		}
+	}
+
+	return ops
+}
+
+// EchoToOpenAPI converts an echo route path (/rooms/:room_id) into
+// the OpenAPI path template format (/rooms/{room_id}). A custom method after
+// a parameter (/scenes/:scene:apply) stays outside it (/scenes/{scene}:apply).
+func EchoToOpenAPI(route string) string {
+	parts := strings.Split(route, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			name, method := strings.TrimPrefix(p, ":"), ""
+			if j := strings.Index(name, ":"); j != -1 {
+				name, method = name[:j], name[j:]
+			}
+
+			parts[i] = fmt.Sprintf("{%s}%s", name, method)
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// resolve replaces every schema $ref with the component it points at
+// and compiles any patterns found along the way
+func (s *Spec) resolve() error {
+	seen := map[*Schema]bool{}
+
+	var walk func(sch *Schema) (*Schema, error)
+	walk = func(sch *Schema) (*Schema, error) {
+		if sch == nil {
+			return nil, nil
+		}
+
+		if sch.Ref != "" {
+			name := strings.TrimPrefix(sch.Ref, "#/components/schemas/")
+			ref, ok := s.Components.Schemas[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown schema reference %q", sch.Ref)
+			}
+
+			return walk(ref)
+		}
+
+		if seen[sch] {
+			return sch, nil
+		}
+		seen[sch] = true
+
+		if kw := sch.composition(); kw != "" {
+			return nil, fmt.Errorf("%s is not supported by the validator", kw)
+		}
+
+		if sch.Pattern != "" {
+			re, err := regexp.Compile(sch.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", sch.Pattern, err)
+			}
+
+			sch.pattern = re
+		}
+
+		var err error
+		for name, prop := range sch.Properties {
+			if sch.Properties[name], err = walk(prop); err != nil {
+				return nil, err
+			}
+		}
+
+		if sch.Items, err = walk(sch.Items); err != nil {
+			return nil, err
+		}
+
+		if sch.AdditionalProperties, err = walk(sch.AdditionalProperties); err != nil {
+			return nil, err
+		}
+
+		return sch, nil
+	}
+
+	var err error
+	for name, sch := range s.Components.Schemas {
+		if s.Components.Schemas[name], err = walk(sch); err != nil {
+			return err
+		}
+	}
+
+	params := func(ps []Parameter) error {
+		for i := range ps {
+			if ps[i].Schema, err = walk(ps[i].Schema); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	content := func(c map[string]MediaType) error {
+		for ct, mt := range c {
+			if mt.Schema, err = walk(mt.Schema); err != nil {
+				return err
+			}
+
+			c[ct] = mt
+		}
+
+		return nil
+	}
+
+	for path, item := range s.Paths {
+		if err := params(item.Parameters); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, op := range item.operations() {
+			if err := params(op.Parameters); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			if op.RequestBody != nil {
+				if err := content(op.RequestBody.Content); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+
+			for _, res := range op.Responses {
+				if err := content(res.Content); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
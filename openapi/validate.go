@@ -0,0 +1,300 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes a single part of a request or response
+// that does not match the spec
+type ValidationError struct {
+	In     string `json:"in"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s: %s", e.In, e.Reason)
+	}
+
+	return fmt.Sprintf("%s %s: %s", e.In, e.Name, e.Reason)
+}
+
+// ValidationErrors is a collection of every problem found during a validation
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateParameters checks the given path, query and header values against params
+func ValidateParameters(params []Parameter, path func(string) string, query map[string][]string, header http.Header) ValidationErrors {
+	var errs ValidationErrors
+
+	for _, p := range params {
+		var vals []string
+		switch p.In {
+		case "path":
+			if v := path(p.Name); v != "" {
+				vals = []string{v}
+			}
+		case "query":
+			vals = query[p.Name]
+		case "header":
+			vals = header.Values(p.Name)
+		default:
+			continue
+		}
+
+		if len(vals) == 0 {
+			if p.Required {
+				errs = append(errs, ValidationError{In: p.In, Name: p.Name, Reason: "is required"})
+			}
+
+			continue
+		}
+
+		if p.Schema == nil {
+			continue
+		}
+
+		// an explode: true array is only given as repeated values, so commas are part of each value
+		split := p.Explode == nil || !*p.Explode
+		for _, e := range p.Schema.validateString(vals, split) {
+			errs = append(errs, ValidationError{In: p.In, Name: p.Name, Reason: e})
+		}
+	}
+
+	return errs
+}
+
+// ValidateBody checks a JSON document against the given schema. in is used
+// to describe where the document came from (e.g. "body" or "response")
+func ValidateBody(sch *Schema, in string, body []byte) ValidationErrors {
+	var doc interface{}
+
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return ValidationErrors{{In: in, Reason: fmt.Sprintf("invalid json: %s", err)}}
+	}
+
+	var errs ValidationErrors
+	for _, e := range sch.validate(doc, "") {
+		errs = append(errs, ValidationError{In: in, Name: e.path, Reason: e.reason})
+	}
+
+	return errs
+}
+
+// validateString checks raw parameter values against the schema, converting
+// them to the schema's type first. Arrays accept repeated values, and comma
+// separated ones if split is set.
+func (s *Schema) validateString(vals []string, split bool) []string {
+	if s.Type == "array" {
+		items := vals
+		if split {
+			items = nil
+			for _, v := range vals {
+				items = append(items, strings.Split(v, ",")...)
+			}
+		}
+
+		var reasons []string
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			reasons = append(reasons, fmt.Sprintf("must have at least %d values", *s.MinItems))
+		}
+
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			reasons = append(reasons, fmt.Sprintf("must have at most %d values", *s.MaxItems))
+		}
+
+		if s.Items != nil {
+			for _, item := range items {
+				reasons = append(reasons, s.Items.validateString([]string{item}, false)...)
+			}
+		}
+
+		return reasons
+	}
+
+	if len(vals) > 1 {
+		return []string{"must only be given once"}
+	}
+
+	var v interface{}
+	switch s.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(vals[0], 10, 64); err != nil {
+			return []string{fmt.Sprintf("%q is not an integer", vals[0])}
+		}
+
+		v = json.Number(vals[0])
+	case "number":
+		if _, err := strconv.ParseFloat(vals[0], 64); err != nil {
+			return []string{fmt.Sprintf("%q is not a number", vals[0])}
+		}
+
+		v = json.Number(vals[0])
+	case "boolean":
+		b, err := strconv.ParseBool(vals[0])
+		if err != nil {
+			return []string{fmt.Sprintf("%q is not a boolean", vals[0])}
+		}
+
+		v = b
+	default:
+		v = vals[0]
+	}
+
+	var reasons []string
+	for _, e := range s.validate(v, "") {
+		reasons = append(reasons, e.reason)
+	}
+
+	return reasons
+}
+
+type schemaError struct {
+	path   string
+	reason string
+}
+
+func (s *Schema) validate(v interface{}, path string) []schemaError {
+	fail := func(format string, a ...interface{}) []schemaError {
+		return []schemaError{{path: path, reason: fmt.Sprintf(format, a...)}}
+	}
+
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+
+		return fail("must not be null")
+	}
+
+	var errs []schemaError
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+
+		for _, req := range s.Required {
+			if _, ok := obj[req]; !ok {
+				errs = append(errs, schemaError{path: join(path, req), reason: "is required"})
+			}
+		}
+
+		if s.MinProperties != nil && len(obj) < *s.MinProperties {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("must have at least %d properties", *s.MinProperties)})
+		}
+
+		// check keys in order so errors are stable between calls
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				errs = append(errs, prop.validate(obj[k], join(path, k))...)
+			} else if s.AdditionalProperties != nil {
+				errs = append(errs, s.AdditionalProperties.validate(obj[k], join(path, k))...)
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+
+		if s.MinItems != nil && len(arr) < *s.MinItems {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("must have at least %d items", *s.MinItems)})
+		}
+
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("must have at most %d items", *s.MaxItems)})
+		}
+
+		if s.Items != nil {
+			for i := range arr {
+				errs = append(errs, s.Items.validate(arr[i], fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("%q does not match %s", str, s.Pattern)})
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return fail("must be a %s", s.Type)
+		}
+
+		if s.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				return fail("must be an integer")
+			}
+		}
+
+		f, err := n.Float64()
+		if err != nil {
+			return fail("must be a number")
+		}
+
+		if s.Minimum != nil && f < *s.Minimum {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("must be at least %v", *s.Minimum)})
+		}
+
+		if s.Maximum != nil && f > *s.Maximum {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("must be at most %v", *s.Maximum)})
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errs = append(errs, schemaError{path: path, reason: fmt.Sprintf("%v is not one of %v", v, s.Enum)})
+		}
+	}
+
+	return errs
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
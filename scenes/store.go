@@ -0,0 +1,226 @@
+// Package scenes stores each room's scenes, either in couch or in a local file.
+package scenes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// NoVersion is the version of a room's scenes before any have been saved
+const NoVersion = "0"
+
+// ErrConflict is returned by Put when the room's scenes changed while they were being replaced
+var ErrConflict = errors.New("the scenes changed while they were being saved")
+
+// Store saves each room's scenes, along with a version that changes every time they do
+type Store interface {
+	// Get returns the room's scenes, which is empty if it doesn't have any, and their version
+	Get(roomID string) ([]models.Scene, string, error)
+
+	// Put replaces the room's scenes and returns their new version. check is
+	// given the version being replaced first, and Put stops with its error if
+	// it returns one.
+	Put(roomID string, scenes []models.Scene, check func(version string) error) (string, error)
+}
+
+// CouchStore keeps each room's scenes in a document in the scenes database,
+// with the room's id. Their version is the document's revision.
+type CouchStore struct {
+	DB *db.Service
+}
+
+// doc is a room's document in the scenes database
+type doc struct {
+	ID     string         `json:"_id"`
+	Rev    string         `json:"_rev,omitempty"`
+	Scenes []models.Scene `json:"scenes"`
+}
+
+// Get returns the scenes in the room's document
+func (s *CouchStore) Get(roomID string) ([]models.Scene, string, error) {
+	var d doc
+	err := s.DB.GetDoc(db.ScenesDB, roomID, &d)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return []models.Scene{}, NoVersion, nil
+	case err != nil:
+		return nil, "", fmt.Errorf("scenes/Get: %w", err)
+	}
+
+	if d.Scenes == nil {
+		d.Scenes = []models.Scene{}
+	}
+
+	return d.Scenes, d.Rev, nil
+}
+
+// Put replaces the scenes in the room's document, creating it if it doesn't
+// exist. The document is saved with the revision check was given, so couch
+// refuses it if another change was saved in between.
+func (s *CouchStore) Put(roomID string, scenes []models.Scene, check func(version string) error) (string, error) {
+	d := doc{ID: roomID}
+	err := s.DB.GetDoc(db.ScenesDB, roomID, &d)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return "", fmt.Errorf("scenes/Put: %w", err)
+	}
+
+	version := d.Rev
+	if version == "" {
+		version = NoVersion
+	}
+
+	if err := check(version); err != nil {
+		return "", err
+	}
+
+	d.Scenes = scenes
+	rev, err := s.DB.PutDoc(db.ScenesDB, roomID, &d)
+	switch {
+	case errors.Is(err, db.ErrConflict):
+		return "", ErrConflict
+	case err != nil:
+		return "", fmt.Errorf("scenes/Put: %w", err)
+	}
+
+	return rev, nil
+}
+
+// FileStore keeps every room's scenes in a JSON file, by room id. If Path is
+// empty they are only kept in memory. Their version is a hash of them.
+type FileStore struct {
+	Path string
+
+	mu    sync.Mutex
+	rooms map[string][]models.Scene
+}
+
+// Load reads saved scenes from Path, if it exists
+func (s *FileStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rooms = map[string][]models.Scene{}
+	if s.Path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(s.Path)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("scenes/Load read: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &s.rooms); err != nil {
+		return fmt.Errorf("scenes/Load unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the room's scenes
+func (s *FileStore) Get(roomID string) ([]models.Scene, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, err := fileVersion(s.rooms[roomID])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return append([]models.Scene{}, s.rooms[roomID]...), version, nil
+}
+
+// Put replaces the room's scenes and saves the file. check is called with the
+// lock held, so two changes from the same version can't both be saved.
+func (s *FileStore) Put(roomID string, scenes []models.Scene, check func(version string) error) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rooms == nil {
+		s.rooms = map[string][]models.Scene{}
+	}
+
+	prev, existed := s.rooms[roomID]
+
+	version, err := fileVersion(prev)
+	if err != nil {
+		return "", err
+	}
+
+	if err := check(version); err != nil {
+		return "", err
+	}
+
+	version, err = fileVersion(scenes)
+	if err != nil {
+		return "", err
+	}
+
+	if len(scenes) == 0 {
+		delete(s.rooms, roomID)
+	} else {
+		s.rooms[roomID] = scenes
+	}
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.rooms[roomID] = prev
+		} else {
+			delete(s.rooms, roomID)
+		}
+
+		return "", err
+	}
+
+	return version, nil
+}
+
+// fileVersion returns the version of a room's scenes in a FileStore
+func fileVersion(scenes []models.Scene) (string, error) {
+	if len(scenes) == 0 {
+		return NoVersion, nil
+	}
+
+	b, err := json.Marshal(scenes)
+	if err != nil {
+		return "", fmt.Errorf("scenes/fileVersion marshal: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// save must be called with s.mu held
+func (s *FileStore) save() error {
+	if s.Path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(s.rooms, "", "  ")
+	if err != nil {
+		return fmt.Errorf("scenes/save marshal: %w", err)
+	}
+
+	// write then rename so a crash doesn't leave a partial file
+	tmp := s.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("scenes/save write: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return fmt.Errorf("scenes/save rename: %w", err)
+	}
+
+	return nil
+}
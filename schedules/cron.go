@@ -0,0 +1,260 @@
+package schedules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression: minute hour day-of-month month day-of-week
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+
+	// loc is the time zone the fields are in. If it's nil they are in the zone of the time given.
+	loc *time.Location
+}
+
+// field is the range of values allowed in one field of a cron expression
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// shortcuts are the named expressions cron accepts in place of the five fields
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a five field cron expression. Each field may be *, a
+// value, a range (1-5), a step (*/15 or 8-18/2) or a comma separated list of
+// them. Months and days of the week may be named (jan, mon), and Sunday is
+// either 0 or 7.
+func ParseCron(expr string) (Cron, error) {
+	if s, ok := shortcuts[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = s
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return Cron{}, fmt.Errorf("must have %d fields, not %d", len(fields), len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := fields[i].parse(part)
+		if err != nil {
+			return Cron{}, err
+		}
+
+		sets[i] = set
+	}
+
+	// sunday can be 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return Cron{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: sets[2] == span(fields[2].min, fields[2].max),
+		dowAny: sets[4] == span(0, 6),
+	}, nil
+}
+
+// span returns the set of values from lo to hi, as bits
+func span(lo, hi int) uint64 {
+	var set uint64
+	for v := lo; v <= hi; v++ {
+		set |= 1 << uint(v)
+	}
+
+	return set
+}
+
+// In returns the cron with its fields in the time zone loc
+func (c Cron) In(loc *time.Location) Cron {
+	c.loc = loc
+	return c
+}
+
+// Matches reports whether the cron runs during the minute t is in. Like cron,
+// a minute repeated when the clocks go back only runs crons that run every hour
+// the second time.
+func (c Cron) Matches(t time.Time) bool {
+	if c.loc != nil {
+		t = t.In(c.loc)
+	}
+
+	return c.month&(1<<uint(t.Month())) != 0 && c.day(t) && c.hour&(1<<uint(t.Hour())) != 0 && c.minute&(1<<uint(t.Minute())) != 0 && !c.repeated(t)
+}
+
+// Next returns the first minute after t the cron runs, or the zero time if it
+// doesn't run in the next 5 years
+func (c Cron) Next(t time.Time) time.Time {
+	if c.loc != nil {
+		t = t.In(c.loc)
+	}
+
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+
+	for next.Before(end) {
+		switch {
+		case c.month&(1<<uint(next.Month())) == 0:
+			next = later(next, time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location()))
+		case !c.day(next):
+			next = later(next, time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location()))
+		case c.hour&(1<<uint(next.Hour())) == 0:
+			next = nextHour(next)
+		case c.minute&(1<<uint(next.Minute())) == 0, c.repeated(next):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// nextHour returns the start of the hour after t, which is on a minute. It
+// counts minutes rather than using Truncate, which rounds in UTC and so is off
+// in zones with a half hour offset.
+func nextHour(t time.Time) time.Time {
+	return t.Add(time.Duration(60-t.Minute()) * time.Minute)
+}
+
+// later returns t, unless it isn't after prev, which time.Date can do when t
+// falls in a daylight saving gap, in which case it returns the next hour after prev
+func later(prev, t time.Time) time.Time {
+	if t.After(prev) {
+		return t
+	}
+
+	return nextHour(prev)
+}
+
+// repeated reports whether t is the second time its minute happened, after the
+// clocks went back, and the cron only runs in some hours, so it has already run
+func (c Cron) repeated(t time.Time) bool {
+	if c.hour == span(fields[1].min, fields[1].max) {
+		return false
+	}
+
+	_, offset := t.Zone()
+	_, before := t.Add(-time.Hour).Zone()
+	if before <= offset {
+		return false
+	}
+
+	first := t.Add(-time.Duration(before-offset) * time.Second)
+	return first.Day() == t.Day() && first.Hour() == t.Hour() && first.Minute() == t.Minute()
+}
+
+// day reports whether the cron runs on t's day. Like cron, if both the day of
+// the month and day of the week are restricted, either can match.
+func (c Cron) day(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// parse returns the set of values the field matches, as bits
+func (f field) parse(expr string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		lo, hi, step := f.min, f.max, 1
+
+		rng := part
+		if i := strings.Index(part, "/"); i != -1 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %s: %q", f.name, part)
+			}
+
+			rng, step = part[:i], n
+		}
+
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+
+			var err error
+			if lo, err = f.value(rng[:i]); err != nil {
+				return 0, err
+			}
+
+			if hi, err = f.value(rng[i+1:]); err != nil {
+				return 0, err
+			}
+
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s: %q", f.name, part)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+
+			// a single value with a step runs from the value to the end of the range
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// value parses one value of the field, which may be a name
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s: %q must be between %d and %d", f.name, s, f.min, f.max)
+	}
+
+	return v, nil
+}
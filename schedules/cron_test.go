@@ -0,0 +1,133 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@often",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) didn't return an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+
+		// zone is the time zone the cron is in, or UTC if it's empty
+		zone string
+
+		// from and want are RFC 3339 times
+		from, want string
+	}{
+		// steps
+		{name: "minute step", expr: "*/15 * * * *", from: "2026-10-19T10:07:00Z", want: "2026-10-19T10:15:00Z"},
+		{name: "minute step on the hour", expr: "*/15 * * * *", from: "2026-10-19T10:45:30Z", want: "2026-10-19T11:00:00Z"},
+		{name: "value with a step", expr: "5/20 * * * *", from: "2026-10-19T10:26:00Z", want: "2026-10-19T10:45:00Z"},
+		{name: "range with a step", expr: "0 8-18/2 * * *", from: "2026-10-19T08:01:00Z", want: "2026-10-19T10:00:00Z"},
+		{name: "range with a step ended", expr: "0 8-18/2 * * *", from: "2026-10-19T18:00:00Z", want: "2026-10-20T08:00:00Z"},
+
+		// ranges, lists and names
+		{name: "weekdays", expr: "30 9 * * mon-fri", from: "2026-10-16T10:00:00Z", want: "2026-10-19T09:30:00Z"},
+		{name: "sunday as 7", expr: "0 0 * * 7", from: "2026-10-19T00:00:00Z", want: "2026-10-25T00:00:00Z"},
+		{name: "named months", expr: "0 0 1 jan,jul *", from: "2026-02-01T00:00:00Z", want: "2026-07-01T00:00:00Z"},
+		{name: "next year", expr: "@yearly", from: "2026-10-19T00:00:00Z", want: "2027-01-01T00:00:00Z"},
+		{name: "day of month past the end of the month", expr: "0 0 31 * *", from: "2026-04-01T00:00:00Z", want: "2026-05-31T00:00:00Z"},
+
+		// when both days are restricted either can match
+		{name: "day of month or week by day of month", expr: "0 0 13 * fri", from: "2026-04-11T00:00:00Z", want: "2026-04-13T00:00:00Z"},
+		{name: "day of month or week by day of week", expr: "0 0 13 * fri", from: "2026-04-13T00:00:00Z", want: "2026-04-17T00:00:00Z"},
+		{name: "day of month step restricts", expr: "0 0 */10 * mon", from: "2026-10-19T00:00:00Z", want: "2026-10-21T00:00:00Z"},
+		{name: "every day of month is any", expr: "0 0 1-31 * mon", from: "2026-10-20T00:00:00Z", want: "2026-10-26T00:00:00Z"},
+		{name: "every day of week is any", expr: "0 0 15 * */1", from: "2026-10-19T00:00:00Z", want: "2026-11-15T00:00:00Z"},
+		{name: "every day of week with 7 is any", expr: "0 0 15 * 1-7", from: "2026-10-19T00:00:00Z", want: "2026-11-15T00:00:00Z"},
+
+		// other zones
+		{name: "half hour offset", expr: "0 * * * *", zone: "Asia/Kolkata", from: "2026-10-19T10:10:00+05:30", want: "2026-10-19T11:00:00+05:30"},
+		{name: "spring forward skips the missing hour", expr: "30 2 * * *", zone: "America/Denver", from: "2026-03-07T12:00:00-07:00", want: "2026-03-09T02:30:00-06:00"},
+		{name: "spring forward every half hour", expr: "*/30 * * * *", zone: "America/Denver", from: "2026-03-08T01:45:00-07:00", want: "2026-03-08T03:00:00-06:00"},
+		{name: "fall back first time", expr: "30 1 * * *", zone: "America/Denver", from: "2026-11-01T00:00:00-06:00", want: "2026-11-01T01:30:00-06:00"},
+		{name: "fall back runs once", expr: "30 1 * * *", zone: "America/Denver", from: "2026-11-01T01:30:00-06:00", want: "2026-11-02T01:30:00-07:00"},
+		{name: "fall back after the repeated hour", expr: "0 2 * * *", zone: "America/Denver", from: "2026-11-01T01:30:00-06:00", want: "2026-11-01T02:00:00-07:00"},
+		{name: "fall back every half hour", expr: "*/30 * * * *", zone: "America/Denver", from: "2026-11-01T01:30:00-06:00", want: "2026-11-01T01:00:00-07:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("unable to parse %q: %s", tt.expr, err)
+			}
+
+			if tt.zone != "" {
+				loc, err := time.LoadLocation(tt.zone)
+				if err != nil {
+					t.Fatalf("unable to load %s: %s", tt.zone, err)
+				}
+
+				c = c.In(loc)
+			}
+
+			got := c.Next(parseTime(t, tt.from))
+			want := parseTime(t, tt.want)
+			if !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got.Format(time.RFC3339), tt.want)
+			}
+
+			if !c.Matches(want) {
+				t.Errorf("doesn't match %s", tt.want)
+			}
+		})
+	}
+}
+
+func TestCronMatchesRepeatedMinuteOnce(t *testing.T) {
+	loc, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatalf("unable to load America/Denver: %s", err)
+	}
+
+	c, err := ParseCron("30 1 * * *")
+	if err != nil {
+		t.Fatalf("unable to parse: %s", err)
+	}
+
+	c = c.In(loc)
+	if !c.Matches(parseTime(t, "2026-11-01T01:30:00-06:00")) {
+		t.Errorf("doesn't match the first 1:30")
+	}
+
+	if c.Matches(parseTime(t, "2026-11-01T01:30:00-07:00")) {
+		t.Errorf("matches the second 1:30")
+	}
+}
+
+func parseTime(t *testing.T, s string) time.Time {
+	t.Helper()
+
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("unable to parse %s: %s", s, err)
+	}
+
+	return v
+}
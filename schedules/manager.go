@@ -0,0 +1,584 @@
+// Package schedules runs cron-style schedules of changes to rooms' state, such
+// as turning every display in a building off at night.
+package schedules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// schedules can be in any time zone, even where the system has no zoneinfo
+	_ "time/tzdata"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// ErrNotFound is returned when a schedule doesn't exist
+var ErrNotFound = errors.New("The requested schedule was not found")
+
+// ErrInvalid is matched by errors caused by a bad schedule
+var ErrInvalid = errors.New("invalid schedule")
+
+// InvalidError describes what is wrong with a schedule
+type InvalidError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid schedule: %s %s", e.Field, e.Reason)
+}
+
+func (e *InvalidError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// maxRuns is how many runs are kept for each schedule
+const maxRuns = 100
+
+// RoomLister finds the rooms in a building
+type RoomLister interface {
+	GetBuildingRoomIDs(bldgAbbr string) ([]string, error)
+}
+
+// Runner makes a schedule's change to a room
+type Runner interface {
+	RunScheduleAction(roomID string, action models.ScheduleAction) error
+}
+
+// Manager keeps track of schedules and runs them when they are due
+type Manager struct {
+	// Path is the file schedules and their runs are saved in. If it is
+	// empty they are only kept in memory.
+	Path string
+
+	Rooms  RoomLister
+	Runner Runner
+
+	// Concurrency is how many rooms a run changes at once
+	Concurrency int
+
+	mu        sync.Mutex
+	schedules map[string]models.Schedule
+	crons     map[string]Cron
+	runs      map[string][]models.ScheduleRun
+}
+
+// file is what is saved at Path
+type file struct {
+	Schedules []models.Schedule    `json:"schedules"`
+	Runs      []models.ScheduleRun `json:"runs"`
+}
+
+// Load reads saved schedules from Path, if it exists
+func (m *Manager) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	if m.Path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(m.Path)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("schedules/Load read: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("schedules/Load unmarshal: %w", err)
+	}
+
+	for _, sched := range f.Schedules {
+		cron, err := ParseCron(sched.Cron)
+		if err != nil {
+			return fmt.Errorf("schedules/Load %s: %w", sched.ScheduleID, err)
+		}
+
+		loc, err := location(sched.TimeZone)
+		if err != nil {
+			return fmt.Errorf("schedules/Load %s: %w", sched.ScheduleID, err)
+		}
+
+		m.schedules[sched.ScheduleID] = sched
+		m.crons[sched.ScheduleID] = cron.In(loc)
+	}
+
+	for _, run := range f.Runs {
+		m.runs[run.ScheduleID] = append(m.runs[run.ScheduleID], run)
+	}
+
+	return nil
+}
+
+// Start runs schedules when they are due until ctx is done. Each minute, every
+// enabled schedule whose cron matches it, in the schedule's time zone, is run.
+// Every minute since the last wake is checked, so minutes aren't lost when the
+// timer fires late, and none are run twice when the clock is set back.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	m.init()
+	m.mu.Unlock()
+
+	go func() {
+		last := time.Now().Truncate(time.Minute)
+
+		for {
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(next.Sub(now)):
+			}
+
+			now = time.Now().Truncate(time.Minute)
+			for t := last.Add(time.Minute); !t.After(now); t = t.Add(time.Minute) {
+				for _, sched := range m.due(t) {
+					go m.run(sched)
+				}
+
+				last = t
+			}
+		}
+	}()
+}
+
+// init must be called with m.mu held
+func (m *Manager) init() {
+	if m.schedules != nil {
+		return
+	}
+
+	m.schedules = map[string]models.Schedule{}
+	m.crons = map[string]Cron{}
+	m.runs = map[string][]models.ScheduleRun{}
+}
+
+// List returns every schedule, oldest first
+func (m *Manager) List() []models.Schedule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scheds := []models.Schedule{}
+	for _, sched := range m.schedules {
+		scheds = append(scheds, sched)
+	}
+
+	sort.Slice(scheds, func(i, j int) bool {
+		if scheds[i].Created.Equal(scheds[j].Created) {
+			return scheds[i].ScheduleID < scheds[j].ScheduleID
+		}
+		return scheds[i].Created.Before(scheds[j].Created)
+	})
+
+	return scheds
+}
+
+// Get returns the schedule with the given id
+func (m *Manager) Get(id string) (models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sched, ok := m.schedules[id]
+	if !ok {
+		return sched, ErrNotFound
+	}
+
+	return sched, nil
+}
+
+// Create adds a new schedule
+func (m *Manager) Create(in models.ScheduleInput) (models.Schedule, error) {
+	cron, err := validate(in)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	now := time.Now().UTC()
+	sched := models.Schedule{
+		ScheduleID: avid.Random(16),
+		Created:    now,
+		Updated:    now,
+	}
+	apply(&sched, in)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+	m.schedules[sched.ScheduleID] = sched
+	m.crons[sched.ScheduleID] = cron
+	if err := m.save(); err != nil {
+		delete(m.schedules, sched.ScheduleID)
+		delete(m.crons, sched.ScheduleID)
+		return models.Schedule{}, err
+	}
+
+	return sched, nil
+}
+
+// Update replaces a schedule's name, cron, time zone, targets, action and whether it is enabled.
+// If check isn't nil, it is given the current schedule and the update is only
+// made if it returns nil, which is checked while no other change can be made.
+func (m *Manager) Update(id string, in models.ScheduleInput, check func(models.Schedule) error) (models.Schedule, error) {
+	cron, err := validate(in)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, ok := m.schedules[id]
+	if !ok {
+		return prev, ErrNotFound
+	}
+
+	if check != nil {
+		if err := check(prev); err != nil {
+			return models.Schedule{}, err
+		}
+	}
+
+	sched := prev
+	sched.Updated = time.Now().UTC()
+	apply(&sched, in)
+
+	prevCron := m.crons[id]
+	m.schedules[id] = sched
+	m.crons[id] = cron
+	if err := m.save(); err != nil {
+		m.schedules[id] = prev
+		m.crons[id] = prevCron
+		return models.Schedule{}, err
+	}
+
+	return sched, nil
+}
+
+// Delete removes a schedule and its runs. check is used like it is by Update.
+func (m *Manager) Delete(id string, check func(models.Schedule) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sched, ok := m.schedules[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if check != nil {
+		if err := check(sched); err != nil {
+			return err
+		}
+	}
+
+	cron, runs := m.crons[id], m.runs[id]
+	delete(m.schedules, id)
+	delete(m.crons, id)
+	delete(m.runs, id)
+
+	if err := m.save(); err != nil {
+		m.schedules[id] = sched
+		m.crons[id] = cron
+		m.runs[id] = runs
+		return err
+	}
+
+	return nil
+}
+
+// Runs returns the schedule's most recent runs, newest first. If roomID isn't
+// empty, only runs that changed the room are returned, with only its result.
+func (m *Manager) Runs(id, roomID string) ([]models.ScheduleRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.schedules[id]; !ok {
+		return nil, ErrNotFound
+	}
+
+	runs := []models.ScheduleRun{}
+	for i := len(m.runs[id]) - 1; i >= 0; i-- {
+		run := m.runs[id][i]
+		if roomID == "" {
+			runs = append(runs, run)
+			continue
+		}
+
+		for _, res := range run.Rooms {
+			if res.RoomID != roomID {
+				continue
+			}
+
+			run.Rooms = []models.ScheduleRoomResult{res}
+			run.Succeeded, run.Failed = 0, 0
+			if res.Succeeded {
+				run.Succeeded = 1
+			} else {
+				run.Failed = 1
+			}
+
+			runs = append(runs, run)
+			break
+		}
+	}
+
+	return runs, nil
+}
+
+// due returns the enabled schedules that run during the minute t is in
+func (m *Manager) due(t time.Time) []models.Schedule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var scheds []models.Schedule
+	for id, sched := range m.schedules {
+		if sched.Enabled && m.crons[id].Matches(t) {
+			scheds = append(scheds, sched)
+		}
+	}
+
+	return scheds
+}
+
+// run makes the schedule's change to each of its rooms and records how it went
+func (m *Manager) run(sched models.Schedule) {
+	run := models.ScheduleRun{
+		RunID:      avid.Random(16),
+		ScheduleID: sched.ScheduleID,
+		Started:    time.Now().UTC(),
+		Rooms:      []models.ScheduleRoomResult{},
+	}
+
+	log.Log.Info("running schedule", zap.String("schedule", sched.ScheduleID), zap.String("name", sched.Name))
+
+	roomIDs, err := m.targetRooms(sched.Targets)
+	if err != nil {
+		run.Error = err.Error()
+		log.Log.Error("unable to find the schedule's rooms", zap.String("schedule", sched.ScheduleID), zap.Error(err))
+	}
+
+	concurrency := m.Concurrency
+	if concurrency < 1 {
+		concurrency = 8
+	}
+
+	results := make([]models.ScheduleRoomResult, len(roomIDs))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i := range roomIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = models.ScheduleRoomResult{RoomID: roomIDs[i], Succeeded: true}
+			if err := m.Runner.RunScheduleAction(roomIDs[i], sched.Action); err != nil {
+				results[i].Succeeded = false
+				results[i].Error = err.Error()
+				log.Log.Warn("schedule failed in room", zap.String("schedule", sched.ScheduleID), zap.String("room", roomIDs[i]), zap.Error(err))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res.Succeeded {
+			run.Succeeded++
+		} else {
+			run.Failed++
+		}
+	}
+
+	run.Rooms = append(run.Rooms, results...)
+	run.Finished = time.Now().UTC()
+
+	log.Log.Info("ran schedule", zap.String("schedule", sched.ScheduleID), zap.Int("succeeded", run.Succeeded), zap.Int("failed", run.Failed))
+	m.addRun(run)
+}
+
+// targetRooms returns the id of every room the targets include, sorted
+func (m *Manager) targetRooms(targets models.ScheduleTargets) ([]string, error) {
+	seen := map[string]bool{}
+	for _, id := range targets.RoomIDs {
+		seen[id] = true
+	}
+
+	var errs []string
+	for _, bldg := range targets.BldgAbbrs {
+		ids, err := m.Rooms.GetBuildingRoomIDs(bldg)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		for _, id := range ids {
+			seen[id] = true
+		}
+	}
+
+	roomIDs := make([]string, 0, len(seen))
+	for id := range seen {
+		roomIDs = append(roomIDs, id)
+	}
+	sort.Strings(roomIDs)
+
+	if len(errs) > 0 {
+		return roomIDs, errors.New(strings.Join(errs, "; "))
+	}
+
+	return roomIDs, nil
+}
+
+// addRun records a run, if the schedule still exists
+func (m *Manager) addRun(run models.ScheduleRun) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.schedules[run.ScheduleID]; !ok {
+		return
+	}
+
+	runs := append(m.runs[run.ScheduleID], run)
+	if len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+
+	m.runs[run.ScheduleID] = runs
+	if err := m.save(); err != nil {
+		log.Log.Error("unable to save schedule run", zap.Error(err))
+	}
+}
+
+// save must be called with m.mu held
+func (m *Manager) save() error {
+	if m.Path == "" {
+		return nil
+	}
+
+	f := file{
+		Schedules: []models.Schedule{},
+		Runs:      []models.ScheduleRun{},
+	}
+
+	for _, sched := range m.schedules {
+		f.Schedules = append(f.Schedules, sched)
+	}
+
+	for _, runs := range m.runs {
+		f.Runs = append(f.Runs, runs...)
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("schedules/save marshal: %w", err)
+	}
+
+	// write then rename so a crash doesn't leave a partial file
+	tmp := m.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("schedules/save write: %w", err)
+	}
+
+	if err := os.Rename(tmp, m.Path); err != nil {
+		return fmt.Errorf("schedules/save rename: %w", err)
+	}
+
+	return nil
+}
+
+// apply copies the input onto the schedule
+func apply(sched *models.Schedule, in models.ScheduleInput) {
+	sched.Name = in.Name
+	sched.Cron = in.Cron
+	sched.TimeZone = in.TimeZone
+	sched.Targets = in.Targets
+	sched.Action = in.Action
+
+	sched.Enabled = true
+	if in.Enabled != nil {
+		sched.Enabled = *in.Enabled
+	}
+}
+
+func validate(in models.ScheduleInput) (Cron, error) {
+	cron, err := ParseCron(in.Cron)
+	if err != nil {
+		return cron, &InvalidError{Field: "cron", Reason: err.Error()}
+	}
+
+	loc, err := location(in.TimeZone)
+	if err != nil {
+		return cron, &InvalidError{Field: "time_zone", Reason: fmt.Sprintf("%q is not a known time zone", in.TimeZone)}
+	}
+	cron = cron.In(loc)
+
+	if cron.Next(time.Now()).IsZero() {
+		return cron, &InvalidError{Field: "cron", Reason: "never runs"}
+	}
+
+	if len(in.Targets.BldgAbbrs) == 0 && len(in.Targets.RoomIDs) == 0 {
+		return cron, &InvalidError{Field: "targets", Reason: "must include at least one building or room"}
+	}
+
+	for _, bldg := range in.Targets.BldgAbbrs {
+		if bldg == "" || strings.Contains(bldg, "-") {
+			return cron, &InvalidError{Field: "targets.building_abbreviations", Reason: fmt.Sprintf("has invalid building %q", bldg)}
+		}
+	}
+
+	for _, id := range in.Targets.RoomIDs {
+		parts := strings.Split(id, "-")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return cron, &InvalidError{Field: "targets.av_room_ids", Reason: fmt.Sprintf("has invalid room id %q", id)}
+		}
+	}
+
+	disp, out := in.Action.Display, in.Action.AudioOutput
+	displayChanges := disp != nil && (disp.Powered != nil || disp.Blanked != nil || disp.Input != nil)
+	outputChanges := out != nil && (out.Volume != nil || out.Muted != nil)
+	if !displayChanges && !outputChanges {
+		return cron, &InvalidError{Field: "action", Reason: "must change the displays or audio outputs"}
+	}
+
+	if out != nil && out.Volume != nil && (*out.Volume < 0 || *out.Volume > 100) {
+		return cron, &InvalidError{Field: "action.av_audio_output_state.av_audio_output_volume_level", Reason: "must be between 0 and 100"}
+	}
+
+	return cron, nil
+}
+
+// location returns the time zone with the IANA name, or the translator's own if name is empty
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	// LoadLocation also takes "" and "Local", which mean something else here
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %s", name)
+	}
+
+	return time.LoadLocation(name)
+}
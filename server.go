@@ -1,100 +1,275 @@
 package main
 
 import (
+	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/events"
 	"github.com/byuoitav/uapi-translator/handlers"
+	"github.com/byuoitav/uapi-translator/health"
+	"github.com/byuoitav/uapi-translator/history"
 	"github.com/byuoitav/uapi-translator/log"
 	"github.com/byuoitav/uapi-translator/middleware"
+	"github.com/byuoitav/uapi-translator/openapi"
+	"github.com/byuoitav/uapi-translator/scenes"
+	"github.com/byuoitav/uapi-translator/schedules"
 	"github.com/byuoitav/uapi-translator/services"
+	"github.com/byuoitav/uapi-translator/webhooks"
 	"github.com/labstack/echo"
 	"github.com/spf13/pflag"
 )
 
+//go:embed av-domain.v1.yaml
+var specDoc []byte
+
+// config is everything the translator is set up with, from its flags
+type config struct {
+	OPAURL      string
+	OPAToken    string
+	DisableAuth bool
+
+	DBAddress  string
+	DBUsername string
+	DBPassword string
+
+	SpecPath          string
+	ValidateResponses bool
+
+	EventPollInterval  time.Duration
+	WebhooksFile       string
+	WebhookMaxAttempts int
+	BatchConcurrency   int
+	ScenesFile         string
+	SchedulesFile      string
+
+	HistoryFile      string
+	HistoryRetention time.Duration
+	HistoryBuildings []string
+	HistoryRooms     []string
+
+	HealthBuildings []string
+	HealthInterval  time.Duration
+	HealthThreshold time.Duration
+	HealthTimeout   time.Duration
+}
+
+// server is the translator's router and the background workers its handlers use
+type server struct {
+	router *echo.Echo
+
+	versions *services.VersionCache
+	hub      *events.Hub
+	hooks    *webhooks.Manager
+	scheds   *schedules.Manager
+	recorder *history.Recorder
+	monitor  *health.Monitor
+}
+
 func main() {
+	var cfg config
 	var port int
 	var logLevel int
-	var opaURL string
-	var opaToken string
-	var disableAuth bool
-	var dbAddress string
-	var dbUsername string
-	var dbPassword string
 
 	pflag.IntVarP(&port, "port", "p", 80, "port to run the server on")
 	pflag.IntVarP(&logLevel, "log-level", "l", 2, "level of logging wanted. 1=DEBUG, 2=INFO, 3=WARN, 4=ERROR, 5=PANIC")
-	pflag.StringVar(&opaURL, "opa-url", "", "URL where the OPA server can be found")
-	pflag.StringVar(&opaToken, "opa-token", "", "token to use when calling OPA")
-	pflag.BoolVar(&disableAuth, "disable-auth", false, "disables authz/n checks")
-	pflag.StringVar(&dbAddress, "db-address", "", "address to the couch db")
-	pflag.StringVar(&dbUsername, "db-username", "", "username for the couch db")
-	pflag.StringVar(&dbPassword, "db-password", "", "password for the couch db")
+	pflag.StringVar(&cfg.OPAURL, "opa-url", "", "URL where the OPA server can be found")
+	pflag.StringVar(&cfg.OPAToken, "opa-token", "", "token to use when calling OPA")
+	pflag.BoolVar(&cfg.DisableAuth, "disable-auth", false, "disables authz/n checks")
+	pflag.StringVar(&cfg.DBAddress, "db-address", "", "address to the couch db")
+	pflag.StringVar(&cfg.DBUsername, "db-username", "", "username for the couch db")
+	pflag.StringVar(&cfg.DBPassword, "db-password", "", "password for the couch db")
+	pflag.StringVar(&cfg.SpecPath, "spec", "", "path to an OpenAPI spec to use instead of the embedded one")
+	pflag.BoolVar(&cfg.ValidateResponses, "validate-responses", false, "log responses that do not match the OpenAPI spec")
+	pflag.DurationVar(&cfg.EventPollInterval, "event-poll-interval", 5*time.Second, "how often to poll the AV API for rooms with event subscribers")
+	pflag.StringVar(&cfg.WebhooksFile, "webhooks-file", "", "file to save webhook registrations in. If empty, they are only kept in memory")
+	pflag.IntVar(&cfg.WebhookMaxAttempts, "webhook-max-attempts", 6, "how many times to try a webhook delivery before dead lettering it")
+	pflag.IntVar(&cfg.BatchConcurrency, "batch-concurrency", services.DefaultBatchConcurrency, "how many rooms' state to fetch at once for batch state requests")
+	pflag.StringVar(&cfg.ScenesFile, "scenes-file", "", "file to save room scenes in. If empty, they are saved in couch's scenes database")
+	pflag.StringVar(&cfg.SchedulesFile, "schedules-file", "", "file to save room schedules and their runs in. If empty, they are only kept in memory")
+	pflag.StringVar(&cfg.HistoryFile, "history-file", "", "file to save recorded state history in. If empty, it is only kept in memory")
+	pflag.DurationVar(&cfg.HistoryRetention, "history-retention", 90*24*time.Hour, "how long recorded state history is kept. 0 keeps it forever")
+	pflag.StringSliceVar(&cfg.HistoryBuildings, "history-buildings", nil, "buildings whose display and audio output state is recorded")
+	pflag.StringSliceVar(&cfg.HistoryRooms, "history-rooms", nil, "rooms whose display and audio output state is recorded")
+	pflag.StringSliceVar(&cfg.HealthBuildings, "health-buildings", nil, "buildings whose devices are checked for reachability")
+	pflag.DurationVar(&cfg.HealthInterval, "health-interval", time.Minute, "how often monitored devices are checked")
+	pflag.DurationVar(&cfg.HealthThreshold, "health-threshold", 5*time.Minute, "how long a device is unreachable before it is alerted on")
+	pflag.DurationVar(&cfg.HealthTimeout, "health-timeout", 10*time.Second, "how long checking a room may take before its devices count as unreachable")
 	pflag.Parse()
 
-	setLog := func(level int) error {
-		switch level {
-		case 1:
-			fmt.Printf("\nSetting log level to *debug*\n\n")
-			log.Config.Level.SetLevel(zap.DebugLevel)
-		case 2:
-			fmt.Printf("\nSetting log level to *info*\n\n")
-			log.Config.Level.SetLevel(zap.InfoLevel)
-		case 3:
-			fmt.Printf("\nSetting log level to *warn*\n\n")
-			log.Config.Level.SetLevel(zap.WarnLevel)
-		case 4:
-			fmt.Printf("\nSetting log level to *error*\n\n")
-			log.Config.Level.SetLevel(zap.ErrorLevel)
-		case 5:
-			fmt.Printf("\nSetting log level to *panic*\n\n")
-			log.Config.Level.SetLevel(zap.PanicLevel)
-		default:
-			return errors.New("invalid log level: must be [1-4]")
-		}
-
-		return nil
-	}
-
 	// set the initial log level
 	if err := setLog(logLevel); err != nil {
 		log.Log.Fatal("unable to set log level", zap.Error(err), zap.Int("got", logLevel))
 	}
 
+	srv, err := newServer(cfg)
+	if err != nil {
+		log.Log.Fatal("unable to set up the server", zap.Error(err))
+	}
+
+	srv.start(context.Background())
+
+	addr := fmt.Sprintf(":%d", port)
+	err = srv.router.Start(addr)
+	if err != nil {
+		log.Log.Fatal("failed to start server", zap.Error(err))
+	}
+}
+
+// newServer builds the translator from cfg: its services, the workers behind
+// webhooks, schedules, history and health, and the router serving them all.
+// The workers aren't started until start is called.
+func newServer(cfg config) (*server, error) {
 	router := echo.New()
+	router.Use(middleware.CustomMethods)
 
 	authRouter := router.Group("")
 
+	// authorizes websocket subscriptions and commands
+	var authorizer handlers.Authorizer
+
 	// If authz/n hasn't been disabled
-	if !disableAuth {
-		if opaURL == "" {
-			log.Log.Errorf("No OPA URL was set, but authz has not been disabled")
-			os.Exit(1)
+	if !cfg.DisableAuth {
+		if cfg.OPAURL == "" {
+			return nil, errors.New("no OPA URL was set, but authz has not been disabled")
 		}
 		opaClient := middleware.OPAClient{
-			URL:   opaURL,
-			Token: opaToken,
+			URL:   cfg.OPAURL,
+			Token: cfg.OPAToken,
 		}
 
 		authRouter.Use(opaClient.Authorize)
+		authorizer = &opaClient
+	}
+
+	var spec *openapi.Spec
+	var err error
+	if cfg.SpecPath != "" {
+		spec, err = openapi.Load(cfg.SpecPath)
+	} else {
+		spec, err = openapi.Parse(specDoc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load OpenAPI spec: %w", err)
 	}
 
-	db := db.Service{
-		Address:  dbAddress,
-		Username: dbUsername,
-		Password: dbPassword,
+	validator := middleware.Validator{
+		Spec:              spec,
+		ValidateResponses: cfg.ValidateResponses,
+	}
+
+	authRouter.Use(validator.Validate)
+
+	database := db.Service{
+		Address:  cfg.DBAddress,
+		Username: cfg.DBUsername,
+		Password: cfg.DBPassword,
+	}
+	var sceneStore scenes.Store = &scenes.CouchStore{DB: &database}
+	if cfg.ScenesFile != "" {
+		fileStore := &scenes.FileStore{Path: cfg.ScenesFile}
+		if err := fileStore.Load(); err != nil {
+			return nil, fmt.Errorf("unable to load scenes from %s: %w", cfg.ScenesFile, err)
+		}
+
+		sceneStore = fileStore
+	}
+
+	srv := &server{
+		router:   router,
+		versions: &services.VersionCache{DB: &database},
 	}
 	s := services.Service{
-		DB: &db,
+		DB:               &database,
+		Scenes:           sceneStore,
+		Versions:         srv.versions,
+		BatchConcurrency: cfg.BatchConcurrency,
 	}
+
+	srv.hub = &events.Hub{
+		Source:   &s,
+		Interval: cfg.EventPollInterval,
+	}
+	srv.hooks = &webhooks.Manager{
+		Path:        cfg.WebhooksFile,
+		Hub:         srv.hub,
+		Rooms:       &s,
+		Changes:     &database,
+		Databases:   []string{db.RoomsDB, db.DevicesDB, db.UIConfigDB},
+		MaxAttempts: cfg.WebhookMaxAttempts,
+	}
+	if err := srv.hooks.Load(); err != nil {
+		return nil, fmt.Errorf("unable to load webhooks from %s: %w", cfg.WebhooksFile, err)
+	}
+
+	srv.scheds = &schedules.Manager{
+		Path:        cfg.SchedulesFile,
+		Rooms:       &s,
+		Runner:      &s,
+		Concurrency: cfg.BatchConcurrency,
+	}
+	if err := srv.scheds.Load(); err != nil {
+		return nil, fmt.Errorf("unable to load schedules from %s: %w", cfg.SchedulesFile, err)
+	}
+
+	if len(cfg.HistoryBuildings) > 0 || len(cfg.HistoryRooms) > 0 {
+		store := &history.Store{
+			Path:      cfg.HistoryFile,
+			Retention: cfg.HistoryRetention,
+		}
+		if err := store.Load(); err != nil {
+			return nil, fmt.Errorf("unable to load state history from %s: %w", cfg.HistoryFile, err)
+		}
+
+		srv.recorder = &history.Recorder{
+			Store:     store,
+			Hub:       srv.hub,
+			Rooms:     &s,
+			Buildings: cfg.HistoryBuildings,
+			RoomIDs:   cfg.HistoryRooms,
+		}
+	}
+
+	if len(cfg.HealthBuildings) > 0 {
+		switch {
+		case cfg.HealthInterval <= 0:
+			return nil, errors.New("invalid health interval: must be positive")
+		case cfg.HealthThreshold <= 0:
+			return nil, errors.New("invalid health threshold: must be positive")
+		case cfg.HealthTimeout <= 0:
+			return nil, errors.New("invalid health timeout: must be positive")
+		}
+
+		srv.monitor = &health.Monitor{
+			Devices:     &s,
+			Rooms:       &s,
+			Publisher:   srv.hooks,
+			Buildings:   cfg.HealthBuildings,
+			Interval:    cfg.HealthInterval,
+			Threshold:   cfg.HealthThreshold,
+			Timeout:     cfg.HealthTimeout,
+			Concurrency: cfg.BatchConcurrency,
+		}
+	}
+
 	h := handlers.Service{
-		Services: &s,
+		Services:   &s,
+		Events:     srv.hub,
+		Webhooks:   srv.hooks,
+		Schedules:  srv.scheds,
+		History:    srv.recorder,
+		Health:     srv.monitor,
+		Authorizer: authorizer,
+	}
+	docs := handlers.Docs{
+		Spec: spec,
 	}
 
 	// Status
@@ -102,31 +277,11 @@ func main() {
 		return c.String(http.StatusOK, "Everything is all right!")
 	})
 
-	//Rooms
-	authRouter.GET("/rooms", h.GetRooms)
-	authRouter.GET("/rooms/:room_id", h.GetRoomByID)
-	authRouter.GET("/rooms/:room_id/devices", h.GetRoomDevices)
+	// Spec and docs
+	docs.Register(router)
 
-	//Devices
-	authRouter.GET("/devices", h.GetDevices)
-	authRouter.GET("/devices/:av_device_id", h.GetDeviceByID)
-	authRouter.GET("/devices/:av_device_id/properties", h.GetDeviceProperties)
-	authRouter.GET("/devices/:av_device_id/state", h.GetDeviceState)
-
-	//Inputs
-	authRouter.GET("/inputs", h.GetInputs)
-	authRouter.GET("/inputs/:av_device_id", h.GetInputByID)
-
-	//Displays
-	authRouter.GET("/displays", h.GetDisplays)
-	authRouter.GET("/displays/:av_display_id", h.GetDisplayByID)
-	authRouter.GET("/displays/:av_display_id/config", h.GetDisplayConfig)
-	authRouter.GET("/displays/:av_display_id/state", h.GetDisplayState)
-
-	//Audio Outputs
-	authRouter.GET("/audio_outputs", h.GetAudioOutputs)
-	authRouter.GET("/audio_outputs/:av_audio_output_id", h.GetAudioOutputByID)
-	authRouter.GET("/audio_outputs/:av_audio_output_id/state", h.GetAudioOutputState)
+	// AV API
+	h.Register(authRouter)
 
 	// Set log level
 	router.GET("/log/:level", func(c echo.Context) error {
@@ -142,9 +297,64 @@ func main() {
 		return c.String(http.StatusOK, fmt.Sprintf("Set log level to %v", level))
 	})
 
-	addr := fmt.Sprintf(":%d", port)
-	err := router.Start(addr)
-	if err != nil {
-		log.Log.Fatal("failed to start server", zap.Error(err))
+	// Make sure every authorized route is described by the spec
+	undocumented := map[string]bool{
+		"/healthz":      true,
+		"/log/:level":   true,
+		"/openapi.yaml": true,
+		"/openapi.json": true,
+		"/docs":         true,
+		"/docs/*":       true,
+		// websocket messages are described in the README
+		"/ws": true,
+		// catch-alls added by authRouter.Use
+		".":  true,
+		"/*": true,
+	}
+	for _, r := range router.Routes() {
+		if !undocumented[r.Path] && !spec.Describes(r.Method, r.Path) {
+			return nil, fmt.Errorf("route %s %s is not described by the OpenAPI spec", r.Method, r.Path)
+		}
 	}
+
+	return srv, nil
+}
+
+// start runs the server's background workers until ctx is done
+func (srv *server) start(ctx context.Context) {
+	srv.versions.Start(ctx)
+	srv.hooks.Start(ctx)
+	srv.scheds.Start(ctx)
+
+	if srv.recorder != nil {
+		srv.recorder.Start(ctx)
+	}
+
+	if srv.monitor != nil {
+		srv.monitor.Start(ctx)
+	}
+}
+
+func setLog(level int) error {
+	switch level {
+	case 1:
+		fmt.Printf("\nSetting log level to *debug*\n\n")
+		log.Config.Level.SetLevel(zap.DebugLevel)
+	case 2:
+		fmt.Printf("\nSetting log level to *info*\n\n")
+		log.Config.Level.SetLevel(zap.InfoLevel)
+	case 3:
+		fmt.Printf("\nSetting log level to *warn*\n\n")
+		log.Config.Level.SetLevel(zap.WarnLevel)
+	case 4:
+		fmt.Printf("\nSetting log level to *error*\n\n")
+		log.Config.Level.SetLevel(zap.ErrorLevel)
+	case 5:
+		fmt.Printf("\nSetting log level to *panic*\n\n")
+		log.Config.Level.SetLevel(zap.PanicLevel)
+	default:
+		return errors.New("invalid log level: must be [1-4]")
+	}
+
+	return nil
 }
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/byuoitav/common/structs"
+	"github.com/byuoitav/uapi-translator/avid"
 	"github.com/byuoitav/uapi-translator/db"
 	"github.com/byuoitav/uapi-translator/log"
 	"github.com/byuoitav/uapi-translator/models"
@@ -17,26 +19,13 @@ import (
 //Multiple outputs in one preset
 //Find audioDevices in preset - take average volume returned from av api for those displays
 
-func (s *Service) GetAudioOutputs(roomNum, bldgAbbr, devType string) ([]models.AudioOutput, error) {
+func (s *Service) GetAudioOutputs(f Filter) ([]models.AudioOutput, error) {
 	url := fmt.Sprintf("%s/ui-configuration/_find", os.Getenv("DB_ADDRESS"))
-	var query models.UIConfigQuery
-
-	if roomNum != "" && bldgAbbr != "" {
-		log.Log.Info("searching audio outputs by room number and building abbreviation", zap.String("roomNum", roomNum), zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("%s-%s$", bldgAbbr, roomNum)
-	} else if roomNum != "" {
-		log.Log.Info("searching audio outputs by room number", zap.String("roomNum", roomNum))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("-%s$", roomNum)
-	} else if bldgAbbr != "" {
-		log.Log.Info("searching audio outputs by building abbreviation", zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 30
-		query.Selector.ID.Regex = fmt.Sprintf("%s-", bldgAbbr)
-	} else {
-		log.Log.Info("getting all audio outputs")
-		query.Limit = 30
-		query.Selector.ID.GT = "\x00"
+	log.Log.Info("searching audio outputs", zap.Any("filter", f))
+
+	query := db.Query{
+		Selector: f.selector(2),
+		Limit:    allItems,
 	}
 
 	var resp models.AudioOutputResponse
@@ -46,7 +35,7 @@ func (s *Service) GetAudioOutputs(roomNum, bldgAbbr, devType string) ([]models.A
 		return nil, err
 	}
 
-	var audioOutputs []models.AudioOutput
+	audioOutputs := []models.AudioOutput{}
 	for _, rm := range resp.Docs {
 		parts := strings.Split(rm.ID, "-")
 		for i, p := range rm.Presets {
@@ -79,6 +68,17 @@ func (s *Service) GetAudioOutputs(roomNum, bldgAbbr, devType string) ([]models.A
 		}
 	}
 
+	if f.DeviceType != "" {
+		matching := []models.AudioOutput{}
+		for _, out := range audioOutputs {
+			if out.DeviceType == f.DeviceType {
+				matching = append(matching, out)
+			}
+		}
+
+		audioOutputs = matching
+	}
+
 	return audioOutputs, nil
 }
 
@@ -149,48 +149,126 @@ func (s *Service) GetAudioOutputState(id string) (*models.AudioOutputState, erro
 	}
 
 	if index > -1 {
-		//Compare to audio devices in preset
-		var volume int
-		numDevices := 0
-		muted := false
-		for _, dev := range config.Presets[index-1].AudioDevices {
-			i := s.findAudioIndex(dev, room.AudioDevices)
-			if i > -1 {
-				numDevices++
-				volume += room.AudioDevices[i].Volume
-				if room.AudioDevices[i].Muted {
-					muted = true
+		return s.presetAudioState(config.Presets[index-1], &room), nil
+	}
+
+	//Check if the id is found in the independent audio devices
+	for _, p := range config.Presets {
+		for _, dev := range p.IndependentAudioDevices {
+			if dev == parts[2] {
+				if state, ok := s.independentAudioState(dev, &room); ok {
+					return state, nil
 				}
 			}
 		}
-		//Take average of volumes
-		if numDevices > 0 {
-			volume /= numDevices
-		}
-		return &models.AudioOutputState{
-			Volume: volume,
-			Muted:  muted,
-		}, nil
-	} else {
+	}
+
+	log.Log.Infof("no state found for audio output device: %s", id)
+	return nil, fmt.Errorf("no state found for audio output device: %s", id)
+}
 
-		//Check if the id is found in the independent audio devices
+// SetAudioOutputState changes the volume or mute of the audio output. Changing
+// a master audio output changes every audio device in its preset.
+func (s *Service) SetAudioOutputState(id string, update models.AudioOutputStateUpdate) (*models.AudioOutputState, error) {
+	log.Log.Info("setting audio output state", zap.String("id", id))
+	parts, index, err := s.parseOutputID(id)
+	if err != nil {
+		log.Log.Error("provided audio output id is invalid", zap.String("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	if update.Volume != nil && (*update.Volume < 0 || *update.Volume > 100) {
+		return nil, fmt.Errorf("volume must be between 0 and 100")
+	}
+
+	config, err := s.getAudioOutputsFromDB(parts, index, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if index > -1 {
+		names = config.Presets[index-1].AudioDevices
+	} else {
 		for _, p := range config.Presets {
-			for _, dev := range p.IndependentAudioDevices {
-				if dev == parts[2] {
-					i := s.findAudioIndex(dev, room.AudioDevices)
-					if i > -1 {
-						return &models.AudioOutputState{
-							Volume: room.AudioDevices[i].Volume,
-							Muted:  room.AudioDevices[i].Muted,
-						}, nil
-					}
-				}
+			if avid.Contains(p.IndependentAudioDevices, parts[2]) {
+				names = []string{parts[2]}
+				break
 			}
 		}
 	}
 
-	log.Log.Infof("no state found for audio output device: %s", id)
-	return nil, fmt.Errorf("no state found for audio output device: %s", id)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("Audio Output: %s does not exist", id)
+	}
+
+	change := models.RoomStateChange{}
+	for _, name := range names {
+		change.AudioDevices = append(change.AudioDevices, models.AudioDeviceChange{
+			Name:   name,
+			Muted:  update.Muted,
+			Volume: update.Volume,
+		})
+	}
+
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	err = db.SetState(url, "PUT", change, &room)
+	if err != nil {
+		log.Log.Error("failed to set audio output state", zap.String("id", id))
+		return nil, err
+	}
+
+	if index > -1 {
+		return s.presetAudioState(config.Presets[index-1], &room), nil
+	}
+
+	state, ok := s.independentAudioState(parts[2], &room)
+	if !ok {
+		return nil, fmt.Errorf("no state found for audio output device: %s", id)
+	}
+
+	return state, nil
+}
+
+// presetAudioState returns the state of the preset's master volume, which
+// is the average volume of the preset's audio devices, muted if any of them are
+func (s *Service) presetAudioState(preset structs.Preset, room *models.RoomState) *models.AudioOutputState {
+	var volume int
+	numDevices := 0
+	muted := false
+	for _, dev := range preset.AudioDevices {
+		i := s.findAudioIndex(dev, room.AudioDevices)
+		if i > -1 {
+			numDevices++
+			volume += room.AudioDevices[i].Volume
+			if room.AudioDevices[i].Muted {
+				muted = true
+			}
+		}
+	}
+	//Take average of volumes
+	if numDevices > 0 {
+		volume /= numDevices
+	}
+	return &models.AudioOutputState{
+		Volume: volume,
+		Muted:  muted,
+	}
+}
+
+// independentAudioState returns the state of the named audio device. ok is false if it has no state.
+func (s *Service) independentAudioState(name string, room *models.RoomState) (*models.AudioOutputState, bool) {
+	i := s.findAudioIndex(name, room.AudioDevices)
+	if i == -1 {
+		return nil, false
+	}
+
+	return &models.AudioOutputState{
+		Volume: room.AudioDevices[i].Volume,
+		Muted:  room.AudioDevices[i].Muted,
+	}, true
 }
 
 func (s *Service) findAudioIndex(name string, devices []models.StateAudioDevice) int {
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// DefaultBatchConcurrency is how many rooms' state the batch gets fetch at once if BatchConcurrency isn't set
+const DefaultBatchConcurrency = 8
+
+// BatchGetDisplayStates returns the state of each display, in the order they
+// were asked for. The state of each room is only fetched once. A display
+// whose state can't be found has an error instead.
+func (s *Service) BatchGetDisplayStates(ids []string) []models.DisplayStateResult {
+	log.Log.Info("batch getting display states", zap.Int("count", len(ids)))
+	states := s.batchRoomStates(ids)
+
+	results := make([]models.DisplayStateResult, len(ids))
+	for i, id := range ids {
+		results[i].DisplayID = id
+
+		state, err := states.lookup(id)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		disp, ok := state.Displays[id]
+		if !ok {
+			results[i].Error = "no display exists with this id"
+			continue
+		}
+
+		results[i].State = &disp
+	}
+
+	return results
+}
+
+// BatchGetAudioOutputStates returns the state of each audio output, in the
+// order they were asked for. The state of each room is only fetched once. An
+// audio output whose state can't be found has an error instead.
+func (s *Service) BatchGetAudioOutputStates(ids []string) []models.AudioOutputStateResult {
+	log.Log.Info("batch getting audio output states", zap.Int("count", len(ids)))
+	states := s.batchRoomStates(ids)
+
+	results := make([]models.AudioOutputStateResult, len(ids))
+	for i, id := range ids {
+		results[i].OutputID = id
+
+		state, err := states.lookup(id)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		out, ok := state.AudioOutputs[id]
+		if !ok {
+			results[i].Error = "no audio output exists with this id"
+			continue
+		}
+
+		results[i].State = &out
+	}
+
+	return results
+}
+
+// batchStates is the state of every room in a batch, or the error getting it
+type batchStates struct {
+	states map[string]*models.RoomResourceState
+	errs   map[string]error
+}
+
+// lookup returns the state of the room the resource is in
+func (b batchStates) lookup(resourceID string) (*models.RoomResourceState, error) {
+	roomID, ok := resourceRoom(resourceID)
+	if !ok {
+		return nil, fmt.Errorf("invalid id: must be in {BLDG}-{Room}-{Device} format")
+	}
+
+	if err := b.errs[roomID]; err != nil {
+		return nil, err
+	}
+
+	return b.states[roomID], nil
+}
+
+// batchRoomStates gets the state of every room the resources are in, at most
+// BatchConcurrency rooms at a time
+func (s *Service) batchRoomStates(resourceIDs []string) batchStates {
+	var rooms []string
+	seen := map[string]bool{}
+	for _, id := range resourceIDs {
+		if roomID, ok := resourceRoom(id); ok && !seen[roomID] {
+			seen[roomID] = true
+			rooms = append(rooms, roomID)
+		}
+	}
+
+	concurrency := s.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	b := batchStates{
+		states: make(map[string]*models.RoomResourceState, len(rooms)),
+		errs:   map[string]error{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, roomID := range rooms {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(roomID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			state, err := s.GetRoomState(roomID)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case errors.Is(err, db.ErrNotFound):
+				b.errs[roomID] = fmt.Errorf("no room exists with the id %s", roomID)
+			case err != nil:
+				log.Log.Warn("unable to get room state for batch", zap.String("room", roomID), zap.Error(err))
+				b.errs[roomID] = fmt.Errorf("unable to get the state of %s", roomID)
+			default:
+				b.states[roomID] = state
+			}
+		}(roomID)
+	}
+
+	wg.Wait()
+	return b
+}
+
+// resourceRoom returns the room a {BLDG}-{Room}-{Device} id is in
+func resourceRoom(id string) (string, bool) {
+	parts := strings.Split(id, "-")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+
+	return parts[0] + "-" + parts[1], true
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// GetBuildings returns a page of the buildings that have AV rooms, sorted by
+// keys, and how many buildings there are in total. If search is set, only
+// buildings whose abbreviation, name or description contain it are returned.
+// Resources are only totaled if they are in fields.
+func (s *Service) GetBuildings(search string, keys []SortKey, offset, limit int, fields Fields) ([]models.Building, int, error) {
+	log.Log.Info("getting buildings", zap.String("search", search), zap.Int("offset", offset), zap.Int("limit", limit))
+
+	rooms, err := s.DB.GetAllRooms()
+	if err != nil {
+		return nil, 0, fmt.Errorf("services/GetBuildings get rooms: %w", err)
+	}
+
+	docs, err := s.DB.GetBuildings()
+	if err != nil {
+		return nil, 0, fmt.Errorf("services/GetBuildings get buildings: %w", err)
+	}
+
+	byID := map[string]db.Building{}
+	for _, b := range docs {
+		byID[b.ID] = b
+	}
+
+	counts := map[string]int{}
+	for _, rm := range rooms {
+		counts[strings.Split(rm.ID, "-")[0]]++
+	}
+
+	buildings := []models.Building{}
+	for abbr, count := range counts {
+		b := models.Building{
+			BldgAbbr:    abbr,
+			Name:        byID[abbr].Name,
+			Description: byID[abbr].Description,
+			RoomCount:   count,
+		}
+
+		if search == "" || containsFold(search, b.BldgAbbr, b.Name, b.Description) {
+			buildings = append(buildings, b)
+		}
+	}
+
+	if err := Sort(buildings, keys); err != nil {
+		return nil, 0, fmt.Errorf("services/GetBuildings: %w", err)
+	}
+
+	total := len(buildings)
+	start, end := pageBounds(total, offset, limit)
+	buildings = buildings[start:end]
+
+	if !fields.Has("av_resources") {
+		return buildings, total, nil
+	}
+
+	// only total up the resources of the buildings being returned
+	types := map[string]*db.DeviceType{}
+	for i := range buildings {
+		buildings[i].Resources, err = s.buildingResources(buildings[i].BldgAbbr, types)
+		if err != nil {
+			return nil, 0, fmt.Errorf("services/GetBuildings: %w", err)
+		}
+	}
+
+	return buildings, total, nil
+}
+
+// GetBuildingByID returns the building, or nil if it has no AV rooms.
+// Its resources are only totaled if they are in fields.
+func (s *Service) GetBuildingByID(bldgAbbr string, fields Fields) (*models.Building, error) {
+	log.Log.Info("getting building by id", zap.String("id", bldgAbbr))
+
+	rooms, err := s.DB.GetRoomsByBuilding(bldgAbbr)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetBuildingByID get rooms: %w", err)
+	}
+
+	if len(rooms) == 0 {
+		return nil, nil
+	}
+
+	b := &models.Building{
+		BldgAbbr:  bldgAbbr,
+		RoomCount: len(rooms),
+	}
+
+	doc, err := s.DB.GetBuildingByID(bldgAbbr)
+	switch {
+	case err == nil:
+		b.Name = doc.Name
+		b.Description = doc.Description
+	case !errors.Is(err, db.ErrNotFound):
+		return nil, fmt.Errorf("services/GetBuildingByID get building: %w", err)
+	}
+
+	if fields.Has("av_resources") {
+		b.Resources, err = s.buildingResources(bldgAbbr, map[string]*db.DeviceType{})
+		if err != nil {
+			return nil, fmt.Errorf("services/GetBuildingByID: %w", err)
+		}
+	}
+
+	return b, nil
+}
+
+// GetBuildingRooms returns a page of the rooms in the building, sorted by keys,
+// and how many match in total. ok is false if the building has no AV rooms.
+// If search is set, only rooms whose id or description contain it are returned.
+// Resources are only looked up if they are in fields.
+func (s *Service) GetBuildingRooms(bldgAbbr, roomNum, search string, keys []SortKey, offset, limit int, fields Fields) (rooms []models.Room, total int, ok bool, err error) {
+	log.Log.Info("getting building rooms", zap.String("id", bldgAbbr), zap.String("roomNum", roomNum), zap.String("search", search))
+
+	docs, err := s.DB.GetRoomsByBuilding(bldgAbbr)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("services/GetBuildingRooms get rooms: %w", err)
+	}
+
+	if len(docs) == 0 {
+		return nil, 0, false, nil
+	}
+
+	rooms = []models.Room{}
+	for _, rm := range docs {
+		parts := strings.Split(rm.ID, "-")
+		if roomNum != "" && parts[1] != roomNum {
+			continue
+		}
+
+		if search != "" && !containsFold(search, rm.ID, rm.Tags["description"]) {
+			continue
+		}
+
+		rooms = append(rooms, models.Room{
+			RoomID:      rm.ID,
+			RoomNum:     parts[1],
+			BldgAbbr:    parts[0],
+			Description: rm.Tags["description"],
+		})
+	}
+
+	if err := Sort(rooms, keys); err != nil {
+		return nil, 0, false, fmt.Errorf("services/GetBuildingRooms: %w", err)
+	}
+
+	total = len(rooms)
+	start, end := pageBounds(total, offset, limit)
+	rooms = rooms[start:end]
+
+	if !fields.Has("av_resources") {
+		return rooms, total, true, nil
+	}
+
+	for i := range rooms {
+		rooms[i].Resources, err = s.GetRoomResources(rooms[i].RoomID)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("services/GetBuildingRooms get room resources: %w", err)
+		}
+	}
+
+	return rooms, total, true, nil
+}
+
+// buildingResources totals the resources in every room of the building
+func (s *Service) buildingResources(bldgAbbr string, types map[string]*db.DeviceType) ([]models.Resource, error) {
+	devs, err := s.DB.GetDevicesByBuilding(bldgAbbr)
+	if err != nil {
+		return nil, fmt.Errorf("get devices: %w", err)
+	}
+
+	return s.resources(devs, types)
+}
+
+// containsFold reports whether any of vals contain substr, ignoring case
+func containsFold(substr string, vals ...string) bool {
+	substr = strings.ToLower(substr)
+	for _, v := range vals {
+		if strings.Contains(strings.ToLower(v), substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pageBounds returns the start and end indexes of the page in a list of total items
+func pageBounds(total, offset, limit int) (int, int) {
+	if offset > total {
+		offset = total
+	}
+
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	return offset, end
+}
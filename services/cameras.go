@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// The pan, tilt and zoom commands a camera can be sent
+const (
+	CameraPanLeft     = "pan_left"
+	CameraPanRight    = "pan_right"
+	CameraTiltUp      = "tilt_up"
+	CameraTiltDown    = "tilt_down"
+	CameraPanTiltStop = "pan_tilt_stop"
+	CameraZoomIn      = "zoom_in"
+	CameraZoomOut     = "zoom_out"
+	CameraZoomStop    = "zoom_stop"
+)
+
+// cameraPrefix starts the device segment of every camera id
+const cameraPrefix = "Camera"
+
+// cameraControlTimeout is how long a camera has to accept a command. Pan and
+// tilt commands are sent while a button is held, so a slow one is as good as failed.
+const cameraControlTimeout = 5 * time.Second
+
+// ErrCameraControl is returned when the camera's control URL doesn't accept a command
+var ErrCameraControl = errors.New("camera control request failed")
+
+// InvalidControlError is returned when a control request names something it can't act on
+type InvalidControlError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvalidControlError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// GetCameras returns the cameras in the presets of every room matching the filter
+func (s *Service) GetCameras(f Filter) ([]models.Camera, error) {
+	log.Log.Info("searching cameras", zap.Any("filter", f))
+
+	configs, err := s.DB.GetCameraConfigs(f.selector(2))
+	if err != nil {
+		return nil, fmt.Errorf("services/GetCameras get camera configs: %w", err)
+	}
+
+	cams := []models.Camera{}
+	for _, config := range configs {
+		for i, cam := range roomCameras(config) {
+			cams = append(cams, camera(config.ID, i, cam))
+		}
+	}
+
+	if f.Search != "" {
+		text := strings.ToLower(f.Search)
+
+		matched := cams[:0]
+		for _, cam := range cams {
+			if strings.Contains(strings.ToLower(cam.CameraID), text) || strings.Contains(strings.ToLower(cam.Name), text) {
+				matched = append(matched, cam)
+			}
+		}
+
+		cams = matched
+	}
+
+	return cams, nil
+}
+
+// GetCameraByID returns the camera, or nil if there isn't a camera with the id
+func (s *Service) GetCameraByID(id string) (*models.Camera, error) {
+	log.Log.Info("getting camera by id", zap.String("id", id))
+
+	roomID, i, cam, err := s.cameraConfig(id)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetCameraByID: %w", err)
+	}
+
+	if cam == nil {
+		return nil, nil
+	}
+
+	c := camera(roomID, i, *cam)
+	return &c, nil
+}
+
+// ControlCamera recalls one of the camera's presets or sends it a pan, tilt or
+// zoom command, by requesting the control URL in its configuration. It returns
+// false if there isn't a camera with the id.
+func (s *Service) ControlCamera(id string, control models.CameraControl) (bool, error) {
+	log.Log.Info("controlling camera", zap.String("id", id), zap.Any("control", control))
+
+	switch {
+	case control.Preset == "" && control.Command == "":
+		return false, &InvalidControlError{Field: "av_camera_preset", Reason: "one of av_camera_preset or av_camera_command is required"}
+	case control.Preset != "" && control.Command != "":
+		return false, &InvalidControlError{Field: "av_camera_command", Reason: "only one of av_camera_preset or av_camera_command may be given"}
+	}
+
+	_, _, cam, err := s.cameraConfig(id)
+	if err != nil {
+		return false, fmt.Errorf("services/ControlCamera: %w", err)
+	}
+
+	if cam == nil {
+		return false, nil
+	}
+
+	var target string
+	if control.Preset != "" {
+		for _, p := range cam.Presets {
+			if p.DisplayName == control.Preset {
+				target = p.SetPreset
+				break
+			}
+		}
+
+		if target == "" {
+			return true, &InvalidControlError{Field: "av_camera_preset", Reason: "the camera doesn't have a preset named " + control.Preset}
+		}
+	} else {
+		target = cameraCommands(*cam)[control.Command]
+		if target == "" {
+			return true, &InvalidControlError{Field: "av_camera_command", Reason: "the camera doesn't support " + control.Command}
+		}
+	}
+
+	u, err := controlURL(target)
+	if err != nil {
+		return true, fmt.Errorf("services/ControlCamera invalid control url %q: %w", target, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cameraControlTimeout)
+	defer cancel()
+
+	if err := db.GetStateContext(ctx, u, "GET", nil); err != nil {
+		log.Log.Warn("unable to control camera", zap.String("id", id), zap.String("url", u), zap.Error(err))
+		return true, fmt.Errorf("services/ControlCamera %s: %w", id, ErrCameraControl)
+	}
+
+	return true, nil
+}
+
+// cameraConfig returns the configuration of the camera with the id and its
+// index in the room, or a nil camera if there isn't one
+func (s *Service) cameraConfig(id string) (string, int, *db.Camera, error) {
+	roomID, ok := resourceRoom(id)
+	if !ok {
+		return "", 0, nil, nil
+	}
+
+	name := strings.Split(id, "-")[2]
+	if !strings.HasPrefix(name, cameraPrefix) {
+		return "", 0, nil, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(name, cameraPrefix))
+	if err != nil || n < 1 {
+		return "", 0, nil, nil
+	}
+
+	config, err := s.DB.GetCameraConfig(roomID)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return "", 0, nil, nil
+	case err != nil:
+		return "", 0, nil, fmt.Errorf("get camera config: %w", err)
+	}
+
+	cams := roomCameras(*config)
+	if n > len(cams) {
+		return "", 0, nil, nil
+	}
+
+	return roomID, n - 1, &cams[n-1], nil
+}
+
+// roomCameras returns every camera in the room's presets, in the order they
+// first appear. A camera in several presets is only returned once.
+func roomCameras(config db.CameraConfig) []db.Camera {
+	var cams []db.Camera
+	seen := map[string]bool{}
+	for _, p := range config.Presets {
+		for _, cam := range p.Cameras {
+			if seen[cam.DisplayName] {
+				continue
+			}
+
+			seen[cam.DisplayName] = true
+			cams = append(cams, cam)
+		}
+	}
+
+	return cams
+}
+
+// cameraCommands returns the control URL of each command the camera supports
+func cameraCommands(cam db.Camera) map[string]string {
+	commands := map[string]string{
+		CameraPanLeft:     cam.PanLeft,
+		CameraPanRight:    cam.PanRight,
+		CameraTiltUp:      cam.TiltUp,
+		CameraTiltDown:    cam.TiltDown,
+		CameraPanTiltStop: cam.PanTiltStop,
+		CameraZoomIn:      cam.ZoomIn,
+		CameraZoomOut:     cam.ZoomOut,
+		CameraZoomStop:    cam.ZoomStop,
+	}
+
+	for command, u := range commands {
+		if u == "" {
+			delete(commands, command)
+		}
+	}
+
+	return commands
+}
+
+// controlURL resolves a control URL from the configuration. URLs without a
+// host are relative to the AV API.
+func controlURL(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	if u.IsAbs() {
+		return u.String(), nil
+	}
+
+	base, err := url.Parse(os.Getenv("AV_API_URL"))
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(u).String(), nil
+}
+
+// camera makes the i'th camera in the room out of its configuration
+func camera(roomID string, i int, cam db.Camera) models.Camera {
+	parts := strings.Split(roomID, "-")
+
+	presets := make([]string, 0, len(cam.Presets))
+	for _, p := range cam.Presets {
+		presets = append(presets, p.DisplayName)
+	}
+
+	commands := []string{}
+	for command := range cameraCommands(cam) {
+		commands = append(commands, command)
+	}
+	SortStrings(commands)
+
+	return models.Camera{
+		CameraID: fmt.Sprintf("%s-%s%d", roomID, cameraPrefix, i+1),
+		Name:     cam.DisplayName,
+		RoomNum:  parts[1],
+		BldgAbbr: parts[0],
+		Stream:   cam.Stream,
+		Presets:  presets,
+		Commands: commands,
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// GetDeviceTypes returns every device type, sorted by id, with how many devices there are of each
+func (s *Service) GetDeviceTypes() ([]models.DeviceType, error) {
+	log.Log.Info("getting device types")
+
+	docs, err := s.DB.GetDeviceTypes()
+	if err != nil {
+		return nil, fmt.Errorf("services/GetDeviceTypes get device types: %w", err)
+	}
+
+	counts, err := s.DB.GetDeviceTypeCounts()
+	if err != nil {
+		return nil, fmt.Errorf("services/GetDeviceTypes get device counts: %w", err)
+	}
+
+	types := []models.DeviceType{}
+	for _, doc := range docs {
+		types = append(types, deviceType(doc, counts[doc.ID]))
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].DeviceTypeID < types[j].DeviceTypeID
+	})
+
+	return types, nil
+}
+
+// GetDeviceTypeByID returns the device type, or nil if it doesn't exist
+func (s *Service) GetDeviceTypeByID(deviceTypeID string) (*models.DeviceType, error) {
+	log.Log.Info("getting device type by id", zap.String("id", deviceTypeID))
+
+	doc, err := s.DB.GetDeviceTypeByID(deviceTypeID)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("services/GetDeviceTypeByID get device type: %w", err)
+	}
+
+	counts, err := s.DB.GetDeviceTypeCounts()
+	if err != nil {
+		return nil, fmt.Errorf("services/GetDeviceTypeByID get device counts: %w", err)
+	}
+
+	dt := deviceType(*doc, counts[doc.ID])
+	return &dt, nil
+}
+
+// deviceType converts a device type document into its response
+func deviceType(doc db.DeviceType, count int) models.DeviceType {
+	dt := models.DeviceType{
+		DeviceTypeID: doc.ID,
+		Description:  doc.Tags["description"],
+		Roles:        []string{},
+		Ports:        []models.DeviceTypePort{},
+		Commands:     []string{},
+		DeviceCount:  count,
+	}
+
+	for _, role := range doc.Roles {
+		dt.Roles = append(dt.Roles, role.ID)
+	}
+
+	for _, port := range doc.Ports {
+		dt.Ports = append(dt.Ports, models.DeviceTypePort{
+			PortID:       port.ID,
+			FriendlyName: port.FriendlyName,
+			PortType:     port.PortType,
+		})
+	}
+
+	for _, cmd := range doc.Commands {
+		dt.Commands = append(dt.Commands, cmd.ID)
+	}
+
+	return dt
+}
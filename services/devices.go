@@ -12,45 +12,35 @@ import (
 	"github.com/byuoitav/uapi-translator/models"
 )
 
-func (s *Service) GetDevices(roomNum, bldgAbbr, devType string) ([]models.Device, error) {
+func (s *Service) GetDevices(f Filter) ([]models.Device, error) {
 	url := fmt.Sprintf("%s/devices/_find", os.Getenv("DB_ADDRESS"))
-	var query models.DeviceQuery
+	log.Log.Info("searching devices", zap.Any("filter", f))
 
-	if devType != "" {
-		log.Log.Info("searching with device type", zap.String("devType", devType))
-		query.Selector.DevType = &models.DeviceTypeQuery{
-			ID: &models.CouchSearch{
-				Regex: devType,
-			},
-		}
+	query := db.Query{
+		Selector: f.selector(3),
+		Limit:    allItems,
+	}
+
+	if f.DeviceType != "" {
+		query.Selector.Eq("type._id", f.DeviceType)
+	}
+
+	if len(f.Roles) > 0 {
+		query.Selector.ElemMatch("roles", db.Selector{}.In("_id", f.Roles...))
 	}
 
-	if roomNum != "" && bldgAbbr != "" {
-		log.Log.Info("searching devices by room number and building abbreviation", zap.String("roomNum", roomNum), zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("%s-%s-", bldgAbbr, roomNum)
-	} else if roomNum != "" {
-		log.Log.Info("searching devices by room number", zap.String("roomNum", roomNum))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("%s-", roomNum)
-	} else if bldgAbbr != "" {
-		log.Log.Info("searching devices by building abbreviation", zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 30 //Todo: get a definite answer on the limit
-		query.Selector.ID.Regex = bldgAbbr
-	} else {
-		log.Log.Info("getting all devices")
-		query.Limit = 30 //Todo: get a definite answer on the limit
-		query.Selector.ID.GT = "\x00"
+	if f.Search != "" {
+		query.Selector.Search(f.Search, "_id", "name", "display_name")
 	}
 
-	var resp models.DeviceResponse
+	var resp db.DeviceResponse
 	err := db.DBSearch(url, "POST", &query, &resp)
 	if err != nil {
 		log.Log.Error("failed to search for devices in database")
 		return nil, fmt.Errorf("Failed to find devices")
 	}
 
-	var devices []models.Device
+	devices := []models.Device{}
 	if resp.Docs == nil {
 		log.Log.Info("no devices resulted from query")
 		return nil, fmt.Errorf("No devices exist under the provided search criteria")
@@ -72,7 +62,7 @@ func (s *Service) GetDevices(roomNum, bldgAbbr, devType string) ([]models.Device
 func (s *Service) GetDeviceByID(deviceID string) (*models.Device, error) {
 	log.Log.Info("searching devices by device id", zap.String("id", deviceID))
 	url := fmt.Sprintf("%s/devices/%s", os.Getenv("DB_ADDRESS"), deviceID)
-	var resp models.DeviceDB
+	var resp db.Device
 
 	err := db.DBSearch(url, "GET", nil, &resp)
 	if err != nil {
@@ -8,31 +8,20 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/byuoitav/common/structs"
+	"github.com/byuoitav/uapi-translator/avid"
 	"github.com/byuoitav/uapi-translator/db"
 	"github.com/byuoitav/uapi-translator/log"
 	"github.com/byuoitav/uapi-translator/models"
 )
 
-func (s *Service) GetDisplays(roomNum, bldgAbbr string) ([]models.Display, error) {
+func (s *Service) GetDisplays(f Filter) ([]models.Display, error) {
 	url := fmt.Sprintf("%s/ui-configuration/_find", os.Getenv("DB_ADDRESS"))
-	var query models.UIConfigQuery
-
-	if roomNum != "" && bldgAbbr != "" {
-		log.Log.Info("searching displays by room number and building abbreviation", zap.String("roomNum", roomNum), zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("%s-%s$", bldgAbbr, roomNum)
-	} else if roomNum != "" {
-		log.Log.Info("searching displays by room number", zap.String("roomNum", roomNum))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("-%s$", roomNum)
-	} else if bldgAbbr != "" {
-		log.Log.Info("searching displays by building abbreviation", zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 30
-		query.Selector.ID.Regex = fmt.Sprintf("%s-", bldgAbbr)
-	} else {
-		log.Log.Info("getting all displays")
-		query.Limit = 30
-		query.Selector.ID.GT = "\x00"
+	log.Log.Info("searching displays", zap.Any("filter", f))
+
+	query := db.Query{
+		Selector: f.selector(2),
+		Limit:    allItems,
 	}
 
 	var resp models.DisplayResponse
@@ -42,17 +31,19 @@ func (s *Service) GetDisplays(roomNum, bldgAbbr string) ([]models.Display, error
 		return nil, err
 	}
 
-	var displays []models.Display
+	displays := []models.Display{}
 	if resp.Docs == nil {
 		log.Log.Info("no displays resulted from query")
 		return nil, fmt.Errorf("No displays exist under the provided search criteria")
 	}
 
 	for _, rm := range resp.Docs {
-		for i := range rm.Presets {
+		for i, p := range rm.Presets {
 			s := strings.Split(rm.ID, "-")
 			next := models.Display{
 				DisplayID: fmt.Sprintf("%s-Display%d", rm.ID, (i + 1)),
+				Name:      displayName(p, i+1),
+				Icon:      p.Icon,
 				RoomNum:   s[1],
 				BldgAbbr:  s[0],
 			}
@@ -71,13 +62,16 @@ func (s *Service) GetDisplayByID(dispID string) (*models.Display, error) {
 		return nil, err
 	}
 
-	_, err = s.getDisplaysFromDB(parts, index, dispID)
+	displays, err := s.getDisplaysFromDB(parts, index, dispID)
 	if err != nil {
 		return nil, err
 	}
 
+	preset := displays.Presets[index-1]
 	display := &models.Display{
 		DisplayID: dispID,
+		Name:      displayName(preset, index),
+		Icon:      preset.Icon,
 		RoomNum:   parts[1],
 		BldgAbbr:  parts[0],
 	}
@@ -97,14 +91,19 @@ func (s *Service) GetDisplayConfig(dispID string) (*models.DisplayConfig, error)
 		return nil, err
 	}
 
-	var devices []string
+	devices := []string{}
 	for _, dev := range displays.Presets[index-1].Displays {
 		devices = append(devices, fmt.Sprintf("%s-%s-%s", parts[0], parts[1], dev))
 	}
 
-	var inputs []string
+	inputs := []models.DisplayInput{}
 	for _, in := range displays.Presets[index-1].Inputs {
-		inputs = append(inputs, fmt.Sprintf("%s-%s-%s", parts[0], parts[1], in))
+		name, icon := inputLabel(displays.InputConfiguration, in)
+		inputs = append(inputs, models.DisplayInput{
+			InputID: fmt.Sprintf("%s-%s-%s", parts[0], parts[1], in),
+			Name:    name,
+			Icon:    icon,
+		})
 	}
 
 	config := &models.DisplayConfig{
@@ -138,41 +137,116 @@ func (s *Service) GetDisplayState(dispID string) (*models.DisplayState, error) {
 		return nil, err
 	}
 
+	state, ok := s.presetDisplayState(fmt.Sprintf("%s-%s", parts[0], parts[1]), displays.Presets[index-1], &room)
+	if !ok {
+		log.Log.Error("failed to find state information for listed displays", zap.String("display id", dispID))
+		return nil, fmt.Errorf("no state information for display: %s", dispID)
+	}
+
+	return state, nil
+}
+
+// SetDisplayState changes the state of every display in the virtual display's preset
+func (s *Service) SetDisplayState(dispID string, update models.DisplayStateUpdate) (*models.DisplayState, error) {
+	log.Log.Info("setting display state", zap.String("id", dispID))
+	parts, index, err := s.parseDisplayID(dispID)
+	if err != nil {
+		log.Log.Error("provided display id is invalid", zap.String("id", dispID), zap.Error(err))
+		return nil, err
+	}
+
+	displays, err := s.getDisplaysFromDB(parts, index, dispID)
+	if err != nil {
+		return nil, err
+	}
+
+	roomID := fmt.Sprintf("%s-%s", parts[0], parts[1])
+	preset := displays.Presets[index-1]
+
+	var power, input *string
+	if update.Powered != nil {
+		p := "standby"
+		if *update.Powered {
+			p = "on"
+		}
+		power = &p
+	}
+
+	if update.Input != nil {
+		in := strings.TrimPrefix(*update.Input, roomID+"-")
+		if !avid.Contains(preset.Inputs, in) {
+			return nil, fmt.Errorf("%s is not an input for display: %s", *update.Input, dispID)
+		}
+		input = &in
+	}
+
+	change := models.RoomStateChange{}
+	for _, name := range preset.Displays {
+		change.Displays = append(change.Displays, models.DisplayChange{
+			Name:    name,
+			Power:   power,
+			Input:   input,
+			Blanked: update.Blanked,
+		})
+	}
+
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	err = db.SetState(url, "PUT", change, &room)
+	if err != nil {
+		log.Log.Error("failed to set display state", zap.String("id", dispID))
+		return nil, err
+	}
+
+	state, ok := s.presetDisplayState(roomID, preset, &room)
+	if !ok {
+		return nil, fmt.Errorf("no state information for display: %s", dispID)
+	}
+
+	return state, nil
+}
+
+// presetDisplayState combines the state of each display in the preset into the
+// state of the preset's virtual display. ok is false if none of them have state.
+func (s *Service) presetDisplayState(roomID string, preset structs.Preset, room *models.RoomState) (*models.DisplayState, bool) {
 	powered, blanked, input := true, true, ""
 	var firstDisplay *models.StateDisplay
-	for _, disp := range room.Displays {
-		if i := s.findDisplayIndex(disp.Name, index, displays); i != -1 {
-			if firstDisplay != nil {
-				if input != disp.Input {
-					log.Log.Info("Different inputs within same display", zap.String("input1", input), zap.String("input2", disp.Input))
-					if input == "" {
-						input = disp.Input
-					}
-				}
-				if firstDisplay.Power != disp.Power {
-					powered = false
-				}
-				if firstDisplay.Blanked != disp.Blanked {
-					blanked = false
-				}
-			} else {
-				firstDisplay = &disp
-				blanked = firstDisplay.Blanked
-				if firstDisplay.Power != "on" {
-					powered = false
+	for i := range room.Displays {
+		disp := room.Displays[i]
+		if s.findDisplayIndex(disp.Name, preset) == -1 {
+			continue
+		}
+
+		if firstDisplay != nil {
+			if input != disp.Input {
+				log.Log.Info("Different inputs within same display", zap.String("input1", input), zap.String("input2", disp.Input))
+				if input == "" {
+					input = disp.Input
 				}
-				input = firstDisplay.Input
 			}
+			if firstDisplay.Power != disp.Power {
+				powered = false
+			}
+			if firstDisplay.Blanked != disp.Blanked {
+				blanked = false
+			}
+		} else {
+			firstDisplay = &disp
+			blanked = firstDisplay.Blanked
+			if firstDisplay.Power != "on" {
+				powered = false
+			}
+			input = firstDisplay.Input
 		}
 	}
 
 	if firstDisplay == nil {
-		log.Log.Error("failed to find state information for listed displays", zap.String("display id", dispID))
-		return nil, fmt.Errorf("no state information for display: %s", dispID)
+		return nil, false
 	}
 
 	if input != "" {
-		input = fmt.Sprintf("%s-%s-%s", parts[0], parts[1], firstDisplay.Input)
+		input = fmt.Sprintf("%s-%s", roomID, firstDisplay.Input)
 	}
 
 	state := &models.DisplayState{
@@ -180,7 +254,16 @@ func (s *Service) GetDisplayState(dispID string) (*models.DisplayState, error) {
 		Blanked: blanked,
 		Input:   input,
 	}
-	return state, nil
+	return state, true
+}
+
+// displayName returns the name of the preset the n'th virtual display is made from
+func displayName(preset structs.Preset, n int) string {
+	if preset.Name != "" {
+		return preset.Name
+	}
+
+	return fmt.Sprintf("Display%d", n)
 }
 
 func (s *Service) parseDisplayID(id string) ([]string, int, error) {
@@ -203,8 +286,8 @@ func (s *Service) parseDisplayID(id string) ([]string, int, error) {
 	return parts, index, nil
 }
 
-func (s *Service) findDisplayIndex(id string, presetIndex int, obj *models.DisplayDB) int {
-	for index, disp := range obj.Presets[presetIndex-1].Displays {
+func (s *Service) findDisplayIndex(id string, preset structs.Preset) int {
+	for index, disp := range preset.Displays {
 		if id == disp {
 			return index
 		}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// Related data that can be embedded in a response with expand=
+const (
+	ExpandDevices = "devices"
+	ExpandState   = "state"
+	ExpandConfig  = "config"
+)
+
+// ExpandRooms embeds the requested related data in each room
+func (s *Service) ExpandRooms(rooms []models.Room, expand []string) error {
+	for i := range rooms {
+		if avid.Contains(expand, ExpandDevices) {
+			devices, err := s.GetRoomDevices(rooms[i].RoomID)
+			if err != nil {
+				return fmt.Errorf("services/ExpandRooms get devices: %w", err)
+			}
+
+			rooms[i].Devices = devices
+		}
+
+		if avid.Contains(expand, ExpandState) {
+			state, err := s.GetRoomState(rooms[i].RoomID)
+			switch {
+			case errors.Is(err, db.ErrNotFound):
+				// the room isn't set up to be controlled, so it has no state
+			case err != nil:
+				return fmt.Errorf("services/ExpandRooms get state: %w", err)
+			default:
+				rooms[i].State = state
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExpandDisplays embeds the requested related data in each display
+func (s *Service) ExpandDisplays(displays []models.Display, expand []string) error {
+	states := roomStates{s: s}
+	for i := range displays {
+		if avid.Contains(expand, ExpandConfig) {
+			config, err := s.GetDisplayConfig(displays[i].DisplayID)
+			if err != nil {
+				return fmt.Errorf("services/ExpandDisplays get config: %w", err)
+			}
+
+			displays[i].Config = config
+		}
+
+		if avid.Contains(expand, ExpandState) {
+			state, err := states.get(displays[i].DisplayID)
+			if err != nil {
+				return fmt.Errorf("services/ExpandDisplays: %w", err)
+			}
+
+			if disp, ok := state.Displays[displays[i].DisplayID]; ok {
+				displays[i].State = &disp
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExpandAudioOutputs embeds the requested related data in each audio output
+func (s *Service) ExpandAudioOutputs(outputs []models.AudioOutput, expand []string) error {
+	states := roomStates{s: s}
+	for i := range outputs {
+		if avid.Contains(expand, ExpandState) {
+			state, err := states.get(outputs[i].OutputID)
+			if err != nil {
+				return fmt.Errorf("services/ExpandAudioOutputs: %w", err)
+			}
+
+			if out, ok := state.AudioOutputs[outputs[i].OutputID]; ok {
+				outputs[i].State = &out
+			}
+		}
+	}
+
+	return nil
+}
+
+// roomStates gets the state of each room once, no matter how many of its resources are expanded
+type roomStates struct {
+	s     *Service
+	rooms map[string]*models.RoomResourceState
+}
+
+// get returns the state of the room the resource is in
+func (r *roomStates) get(resourceID string) (*models.RoomResourceState, error) {
+	parts := strings.SplitN(resourceID, "-", 3)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid id %q", resourceID)
+	}
+
+	roomID := parts[0] + "-" + parts[1]
+	if state, ok := r.rooms[roomID]; ok {
+		return state, nil
+	}
+
+	state, err := r.s.GetRoomState(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("get room state: %w", err)
+	}
+
+	if r.rooms == nil {
+		r.rooms = map[string]*models.RoomResourceState{}
+	}
+
+	r.rooms[roomID] = state
+	return state, nil
+}
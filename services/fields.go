@@ -0,0 +1,25 @@
+package services
+
+// Fields are the properties a caller wants returned. Properties that are
+// expensive to look up are skipped when they aren't wanted. A nil Fields
+// wants every property.
+type Fields map[string]bool
+
+// NewFields returns the Fields wanting the named properties, or nil if there are none
+func NewFields(names ...string) Fields {
+	if len(names) == 0 {
+		return nil
+	}
+
+	f := Fields{}
+	for _, name := range names {
+		f[name] = true
+	}
+
+	return f
+}
+
+// Has reports whether the property is wanted
+func (f Fields) Has(name string) bool {
+	return f == nil || f[name]
+}
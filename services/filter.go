@@ -0,0 +1,93 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/db"
+)
+
+// allItems is the query limit used to find every match, so collections can be
+// sorted before they are limited
+const allItems = 10000
+
+// Filter narrows down the items in a collection. Every filter that is set
+// must match; a filter that takes a list matches any of the values in it.
+// Not every collection supports every filter.
+type Filter struct {
+	BldgAbbrs    []string
+	RoomNum      string
+	DeviceType   string
+	Roles        []string
+	Designations []string
+	Tags         []Tag
+
+	// Search matches names and descriptions that contain it, ignoring case
+	Search string
+
+	// HasResources matches rooms that have every one of the resources
+	HasResources []string
+}
+
+// Tag matches documents with the tag. If Value is empty any value matches.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// ParseTag parses a tag filter in key:value or key form
+func ParseTag(s string) Tag {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) == 1 {
+		return Tag{Key: parts[0]}
+	}
+
+	return Tag{Key: parts[0], Value: parts[1]}
+}
+
+// selector returns a selector matching ids with the given number of segments
+// ({BLDG}-{Room}, or {BLDG}-{Room}-{Device}) in the filter's buildings and room
+func (f Filter) selector(segments int) db.Selector {
+	values := make([][]string, segments)
+	values[0] = f.BldgAbbrs
+	if f.RoomNum != "" {
+		values[1] = []string{f.RoomNum}
+	}
+
+	sel := db.Selector{}.AnySegments("_id", values...)
+
+	// each tag gets its own selector, so repeating a key asks for every value
+	var tags []db.Selector
+	for _, tag := range f.Tags {
+		field := db.Field("tags", tag.Key)
+		if tag.Value == "" {
+			tags = append(tags, db.Selector{}.Exists(field))
+		} else {
+			tags = append(tags, db.Selector{}.Eq(field, tag.Value))
+		}
+	}
+
+	if len(tags) > 0 {
+		sel["$and"] = tags
+	}
+
+	return sel
+}
+
+// hasResources reports whether the resources include every one the filter asks for
+func (f Filter) hasResources(resources []string) bool {
+	for _, want := range f.HasResources {
+		found := false
+		for _, r := range resources {
+			if strings.EqualFold(r, want) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
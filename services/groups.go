@@ -0,0 +1,238 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/common/structs"
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// ErrDisplayNotShowing is returned when displays are shared with a display that is off or isn't showing an input
+var ErrDisplayNotShowing = errors.New("the display must be on and showing an input to be shared")
+
+// GetRoomGroups returns each display in the room that isn't sharing another
+// display, along with the displays sharing it. A display is sharing another
+// when its preset is one of the other's shareable presets and both are on,
+// showing the same input. It returns nil if the room doesn't exist.
+func (s *Service) GetRoomGroups(roomID string) ([]models.DisplayGroup, error) {
+	log.Log.Info("getting room groups", zap.String("room", roomID))
+
+	config, room, err := s.groupState(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetRoomGroups: %w", err)
+	}
+
+	if config == nil {
+		return nil, nil
+	}
+
+	return s.displayGroups(roomID, config.Presets, room), nil
+}
+
+// SetRoomGroup shares the given displays with the display, by switching them
+// to its input, and puts any others that were sharing it in standby. It
+// returns the group the display is in afterwards, or nil if the room or
+// display doesn't exist.
+func (s *Service) SetRoomGroup(roomID, dispID string, update models.DisplayGroupUpdate) (*models.DisplayGroup, error) {
+	log.Log.Info("setting room group", zap.String("room", roomID), zap.String("display", dispID), zap.Strings("shared", update.Shared))
+
+	config, room, err := s.groupState(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("services/SetRoomGroup: %w", err)
+	}
+
+	if config == nil {
+		return nil, nil
+	}
+
+	master := presetIndex(roomID, dispID, len(config.Presets))
+	if master == -1 {
+		return nil, nil
+	}
+
+	shareable := map[int]bool{}
+	for _, i := range shareablePresets(config.Presets, master) {
+		shareable[i] = true
+	}
+
+	share := map[int]bool{}
+	for _, id := range update.Shared {
+		i := presetIndex(roomID, id, len(config.Presets))
+		if !shareable[i] {
+			return nil, &InvalidControlError{Field: "av_shared_display_ids", Reason: id + " can't be shared with " + dispID}
+		}
+
+		share[i] = true
+	}
+
+	change := models.RoomStateChange{}
+	if len(share) > 0 {
+		state, ok := s.presetDisplayState(roomID, config.Presets[master], room)
+		if !ok || !state.Powered || state.Input == "" {
+			return nil, fmt.Errorf("services/SetRoomGroup %s: %w", dispID, ErrDisplayNotShowing)
+		}
+
+		power, input := "on", strings.TrimPrefix(state.Input, roomID+"-")
+		for i := range share {
+			for _, name := range config.Presets[i].Displays {
+				change.Displays = append(change.Displays, models.DisplayChange{Name: name, Power: &power, Input: &input})
+			}
+		}
+	}
+
+	// unshare the displays that aren't sharing it anymore
+	standby := "standby"
+	for _, g := range s.displayGroups(roomID, config.Presets, room) {
+		if g.DisplayID != dispID {
+			continue
+		}
+
+		for _, id := range g.Shared {
+			i := presetIndex(roomID, id, len(config.Presets))
+			if share[i] {
+				continue
+			}
+
+			for _, name := range config.Presets[i].Displays {
+				change.Displays = append(change.Displays, models.DisplayChange{Name: name, Power: &standby})
+			}
+		}
+	}
+
+	if len(change.Displays) > 0 {
+		parts := strings.Split(roomID, "-")
+		url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+		room = &models.RoomState{}
+		if err := db.SetState(url, "PUT", change, room); err != nil {
+			return nil, fmt.Errorf("services/SetRoomGroup set state: %w", err)
+		}
+	}
+
+	for _, g := range s.displayGroups(roomID, config.Presets, room) {
+		if g.DisplayID == dispID || avid.Contains(g.Shared, dispID) {
+			return &g, nil
+		}
+	}
+
+	return nil, fmt.Errorf("services/SetRoomGroup: %s isn't in a group", dispID)
+}
+
+// groupState returns the room's ui-configuration and state, or a nil
+// configuration if the room doesn't exist
+func (s *Service) groupState(roomID string) (*db.UIConfig, *models.RoomState, error) {
+	parts := strings.Split(roomID, "-")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, nil, nil
+	}
+
+	config, err := s.DB.GetUIConfig(roomID)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, nil, nil
+	case err != nil:
+		return nil, nil, fmt.Errorf("get ui config: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	if err := db.GetState(url, "GET", &room); err != nil {
+		return nil, nil, fmt.Errorf("get state: %w", err)
+	}
+
+	return config, &room, nil
+}
+
+// displayGroups groups the room's displays by which ones are sharing each
+// other, going through the presets in order
+func (s *Service) displayGroups(roomID string, presets []structs.Preset, room *models.RoomState) []models.DisplayGroup {
+	states := make([]*models.DisplayState, len(presets))
+	for i := range presets {
+		if state, ok := s.presetDisplayState(roomID, presets[i], room); ok && state.Powered {
+			states[i] = state
+		}
+	}
+
+	grouped := make([]bool, len(presets))
+	groups := []models.DisplayGroup{}
+	for i := range presets {
+		if grouped[i] {
+			continue
+		}
+		grouped[i] = true
+
+		g := models.DisplayGroup{
+			DisplayID:    fmt.Sprintf("%s-Display%d", roomID, i+1),
+			Shared:       []string{},
+			Shareable:    []string{},
+			AudioOutputs: []string{},
+		}
+
+		if states[i] != nil {
+			g.Input = states[i].Input
+		}
+
+		members := []int{i}
+		for _, j := range shareablePresets(presets, i) {
+			id := fmt.Sprintf("%s-Display%d", roomID, j+1)
+			g.Shareable = append(g.Shareable, id)
+
+			if grouped[j] || g.Input == "" || states[j] == nil || states[j].Input != g.Input {
+				continue
+			}
+
+			grouped[j] = true
+			g.Shared = append(g.Shared, id)
+			members = append(members, j)
+		}
+
+		for _, j := range members {
+			if len(presets[j].AudioDevices) > 0 {
+				g.AudioOutputs = append(g.AudioOutputs, fmt.Sprintf("%s-MasterAudio%d", roomID, j+1))
+			}
+		}
+
+		SortStrings(g.Shared)
+		SortStrings(g.Shareable)
+		SortStrings(g.AudioOutputs)
+		groups = append(groups, g)
+	}
+
+	return groups
+}
+
+// shareablePresets returns the index of each of the preset's shareable presets
+func shareablePresets(presets []structs.Preset, i int) []int {
+	var indexes []int
+	for _, name := range presets[i].ShareablePresets {
+		for j := range presets {
+			if j != i && presets[j].Name == name {
+				indexes = append(indexes, j)
+				break
+			}
+		}
+	}
+
+	return indexes
+}
+
+// presetIndex returns the index of the preset a {BLDG}-{Room}-Display{N} id in
+// the room is made from, or -1 if it isn't one of the room's displays
+func presetIndex(roomID, dispID string, count int) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(dispID, roomID+"-Display"))
+	if err != nil || !strings.HasPrefix(dispID, roomID+"-Display") || n < 1 || n > count {
+		return -1
+	}
+
+	return n - 1
+}
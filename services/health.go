@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// errNoState is why a device the AV API left out of a room's state is unreachable
+var errNoState = fmt.Errorf("the AV API returned no state for the device")
+
+// CheckRoomDevices asks the AV API for the room's state once, and returns
+// whether it could reach each display and audio device in the room's presets.
+// The map holds nil for the reachable devices, and why for the rest, keyed by
+// device id. If the AV API can't be reached before ctx is done every device
+// has its error.
+func (s *Service) CheckRoomDevices(ctx context.Context, roomID string) (map[string]error, error) {
+	log.Log.Debug("checking room devices", zap.String("id", roomID))
+	parts := strings.Split(roomID, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid room id")
+	}
+
+	config, err := s.DB.GetUIConfig(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("services/CheckRoomDevices get ui config: %w", err)
+	}
+
+	// a device can be both a display and an audio device, like a TV's speakers
+	displays, audio := map[string]bool{}, map[string]bool{}
+	for _, p := range config.Presets {
+		for _, name := range p.Displays {
+			displays[name] = true
+		}
+
+		for _, list := range [][]string{p.AudioDevices, p.IndependentAudioDevices} {
+			for _, name := range list {
+				audio[name] = true
+			}
+		}
+	}
+
+	names := map[string]bool{}
+	for name := range displays {
+		names[name] = true
+	}
+
+	for name := range audio {
+		names[name] = true
+	}
+
+	devices := make(map[string]error, len(names))
+
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	if err := db.GetStateContext(ctx, url, "GET", &room); err != nil {
+		err = fmt.Errorf("services/CheckRoomDevices get state: %w", err)
+		for name := range names {
+			devices[roomID+"-"+name] = err
+		}
+
+		return devices, nil
+	}
+
+	seenDisplays, seenAudio := map[string]bool{}, map[string]bool{}
+	for _, disp := range room.Displays {
+		// a display the AV API couldn't talk to has no power state
+		if disp.Power != "" {
+			seenDisplays[disp.Name] = true
+		}
+	}
+
+	for _, dev := range room.AudioDevices {
+		seenAudio[dev.Name] = true
+	}
+
+	for name := range names {
+		devices[roomID+"-"+name] = nil
+		if (displays[name] && !seenDisplays[name]) || (audio[name] && !seenAudio[name]) {
+			devices[roomID+"-"+name] = errNoState
+		}
+	}
+
+	return devices, nil
+}
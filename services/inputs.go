@@ -5,32 +5,20 @@ import (
 	"os"
 	"strings"
 
+	"github.com/byuoitav/common/structs"
 	"github.com/byuoitav/uapi-translator/db"
 	"github.com/byuoitav/uapi-translator/log"
 	"github.com/byuoitav/uapi-translator/models"
 	"go.uber.org/zap"
 )
 
-func (s *Service) GetInputs(roomNum, bldgAbbr string) ([]models.Input, error) {
+func (s *Service) GetInputs(f Filter) ([]models.Input, error) {
 	url := fmt.Sprintf("%s/ui-configuration/_find", os.Getenv("DB_ADDRESS"))
-	var query models.UIConfigQuery
-
-	if roomNum != "" && bldgAbbr != "" {
-		log.Log.Info("searching inputs by room number and building abbreviation", zap.String("roomNum", roomNum), zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("%s-%s$", bldgAbbr, roomNum)
-	} else if roomNum != "" {
-		log.Log.Info("searching inputs by room number", zap.String("roomNum", roomNum))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("-%s$", roomNum)
-	} else if bldgAbbr != "" {
-		log.Log.Info("searching inputs by building abbreviation", zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 30
-		query.Selector.ID.Regex = fmt.Sprintf("%s-", bldgAbbr)
-	} else {
-		log.Log.Info("getting all inputs")
-		query.Limit = 30
-		query.Selector.ID.GT = "\x00"
+	log.Log.Info("searching inputs", zap.Any("filter", f))
+
+	query := db.Query{
+		Selector: f.selector(2),
+		Limit:    allItems,
 	}
 
 	var resp models.InputResponse
@@ -40,13 +28,16 @@ func (s *Service) GetInputs(roomNum, bldgAbbr string) ([]models.Input, error) {
 		return nil, err
 	}
 
-	var inputs []models.Input
+	inputs := []models.Input{}
 	for _, rm := range resp.Docs {
 		parts := strings.Split(rm.ID, "-")
 		for _, in := range rm.InputConfiguration {
 			deviceID := fmt.Sprintf("%s-%s", rm.ID, in.Name)
+			name, icon := inputLabel(rm.InputConfiguration, in.Name)
 			next := models.Input{
 				DeviceID:   deviceID,
+				Name:       name,
+				Icon:       icon,
 				RoomNum:    parts[1],
 				BldgAbbr:   parts[0],
 				DeviceType: s.getDeviceType(deviceID),
@@ -69,9 +60,10 @@ func (s *Service) GetInputByID(id string) (*models.Input, error) {
 		return nil, err
 	}
 
-	var query models.UIConfigQuery
-	query.Limit = 1000
-	query.Selector.ID.Regex = fmt.Sprintf("%s-%s$", parts[0], parts[1])
+	query := db.Query{
+		Selector: db.Selector{}.Segments("_id", parts[0], parts[1]),
+		Limit:    1000,
+	}
 	url := fmt.Sprintf("%s/ui-configuration/_find", os.Getenv("DB_ADDRESS"))
 
 	var resp models.InputResponse
@@ -81,8 +73,11 @@ func (s *Service) GetInputByID(id string) (*models.Input, error) {
 		return nil, err
 	}
 
+	name, icon := inputLabel(resp.Docs[0].InputConfiguration, parts[2])
 	input := &models.Input{
 		DeviceID:   device.DeviceID,
+		Name:       name,
+		Icon:       icon,
 		RoomNum:    device.RoomNum,
 		BldgAbbr:   device.BldgAbbr,
 		DeviceType: device.DeviceType,
@@ -93,7 +88,7 @@ func (s *Service) GetInputByID(id string) (*models.Input, error) {
 }
 
 func (s *Service) getInputDisplays(inputID string, resp *models.InputDB) []string {
-	var displays []string
+	displays := []string{}
 	parts := strings.Split(resp.ID, "-")
 	for i, p := range resp.Presets {
 		for _, in := range p.Inputs {
@@ -102,5 +97,36 @@ func (s *Service) getInputDisplays(inputID string, resp *models.InputDB) []strin
 			}
 		}
 	}
+
+	SortStrings(displays)
 	return displays
 }
+
+// inputLabel returns the name and icon the room's touch panels show for the
+// input, falling back to its device name if it isn't configured
+func inputLabel(configs []structs.IOConfiguration, name string) (string, string) {
+	if in, ok := findInputConfig(configs, name); ok {
+		if in.Displayname != nil && *in.Displayname != "" {
+			return *in.Displayname, in.Icon
+		}
+
+		return name, in.Icon
+	}
+
+	return name, ""
+}
+
+// findInputConfig finds the named input's configuration, including in the sub inputs
+func findInputConfig(configs []structs.IOConfiguration, name string) (structs.IOConfiguration, bool) {
+	for _, in := range configs {
+		if in.Name == name {
+			return in, true
+		}
+
+		if sub, ok := findInputConfig(in.SubInputs, name); ok {
+			return sub, true
+		}
+	}
+
+	return structs.IOConfiguration{}, false
+}
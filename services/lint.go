@@ -0,0 +1,231 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// The kinds of issue LintConfig finds
+const (
+	LintMissingUIConfig   = "missing_ui_configuration"
+	LintMissingRoom       = "missing_room"
+	LintDanglingReference = "dangling_reference"
+	LintDuplicate         = "duplicate"
+	LintMissingDeviceType = "missing_device_type"
+	LintUnknownDeviceType = "unknown_device_type"
+)
+
+// LintConfig checks every room's ui-configuration against its devices and
+// their device types, finding the names in each preset that don't have a
+// device, names listed twice, devices without a known type, and rooms that
+// are missing their room or ui-configuration document. If bldgAbbr is empty
+// every room is checked.
+func (s *Service) LintConfig(bldgAbbr string) (*models.LintReport, error) {
+	log.Log.Info("linting room configuration", zap.String("building", bldgAbbr))
+
+	var rooms []db.Room
+	var configs []db.UIConfig
+	var devices []db.Device
+	var err error
+
+	if bldgAbbr != "" {
+		if rooms, err = s.DB.GetRoomsByBuilding(bldgAbbr); err != nil {
+			return nil, fmt.Errorf("services/LintConfig get rooms: %w", err)
+		}
+
+		if configs, err = s.DB.GetUIConfigsByBuilding(bldgAbbr); err != nil {
+			return nil, fmt.Errorf("services/LintConfig get ui configs: %w", err)
+		}
+
+		if devices, err = s.DB.GetDevicesByBuilding(bldgAbbr); err != nil {
+			return nil, fmt.Errorf("services/LintConfig get devices: %w", err)
+		}
+	} else {
+		if rooms, err = s.DB.GetAllRooms(); err != nil {
+			return nil, fmt.Errorf("services/LintConfig get rooms: %w", err)
+		}
+
+		if configs, err = s.DB.GetAllUIConfigs(); err != nil {
+			return nil, fmt.Errorf("services/LintConfig get ui configs: %w", err)
+		}
+
+		if devices, err = s.DB.GetAllDevices(); err != nil {
+			return nil, fmt.Errorf("services/LintConfig get devices: %w", err)
+		}
+	}
+
+	types, err := s.DB.GetDeviceTypes()
+	if err != nil {
+		return nil, fmt.Errorf("services/LintConfig get device types: %w", err)
+	}
+
+	knownTypes := map[string]bool{}
+	for _, t := range types {
+		knownTypes[t.ID] = true
+	}
+
+	deviceTypes := map[string]string{}
+	for _, d := range devices {
+		deviceTypes[d.ID] = deviceTypeID(d)
+	}
+
+	hasRoom := map[string]bool{}
+	for _, r := range rooms {
+		hasRoom[r.ID] = true
+	}
+
+	byRoom := map[string]*db.UIConfig{}
+	for i := range configs {
+		byRoom[configs[i].ID] = &configs[i]
+	}
+
+	ids := make([]string, 0, len(byRoom))
+	for id := range hasRoom {
+		ids = append(ids, id)
+	}
+
+	for id := range byRoom {
+		if !hasRoom[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	report := &models.LintReport{
+		BldgAbbr:     bldgAbbr,
+		RoomsChecked: len(ids),
+		Issues:       []models.LintIssue{},
+	}
+
+	for _, id := range ids {
+		config, ok := byRoom[id]
+		switch {
+		case !ok:
+			report.Issues = append(report.Issues, models.LintIssue{
+				RoomID:  id,
+				Kind:    LintMissingUIConfig,
+				Message: "the room has no ui-configuration",
+			})
+			continue
+		case !hasRoom[id]:
+			report.Issues = append(report.Issues, models.LintIssue{
+				RoomID:  id,
+				Kind:    LintMissingRoom,
+				Message: "the ui-configuration has no room document",
+			})
+		}
+
+		report.Issues = append(report.Issues, lintUIConfig(id, config, deviceTypes, knownTypes)...)
+	}
+
+	return report, nil
+}
+
+// lintUIConfig checks a room's presets. deviceTypes is the type of each
+// device, by device id, and knownTypes is every device type that exists.
+func lintUIConfig(roomID string, config *db.UIConfig, deviceTypes map[string]string, knownTypes map[string]bool) []models.LintIssue {
+	var issues []models.LintIssue
+	checked := map[string]bool{}
+
+	presetNames := map[string]int{}
+	for i, p := range config.Presets {
+		if p.Name != "" {
+			if first, ok := presetNames[p.Name]; ok {
+				issues = append(issues, models.LintIssue{
+					RoomID:    roomID,
+					Kind:      LintDuplicate,
+					Field:     fmt.Sprintf("presets[%d].name", i),
+					Reference: p.Name,
+					Message:   fmt.Sprintf("presets[%d] has the same name", first),
+				})
+			} else {
+				presetNames[p.Name] = i
+			}
+		}
+
+		lists := []struct {
+			field string
+			names []string
+		}{
+			{"displays", p.Displays},
+			{"inputs", p.Inputs},
+			{"audioDevices", p.AudioDevices},
+			{"independentAudioDevices", p.IndependentAudioDevices},
+		}
+
+		for _, list := range lists {
+			field := fmt.Sprintf("presets[%d].%s", i, list.field)
+			seen := map[string]bool{}
+
+			for _, name := range list.names {
+				if seen[name] {
+					issues = append(issues, models.LintIssue{
+						RoomID:    roomID,
+						Kind:      LintDuplicate,
+						Field:     field,
+						Reference: name,
+						Message:   fmt.Sprintf("%s is listed more than once", name),
+					})
+					continue
+				}
+				seen[name] = true
+
+				devID := fmt.Sprintf("%s-%s", roomID, name)
+				typeID, ok := deviceTypes[devID]
+				if !ok {
+					issues = append(issues, models.LintIssue{
+						RoomID:    roomID,
+						Kind:      LintDanglingReference,
+						Field:     field,
+						Reference: devID,
+						Message:   fmt.Sprintf("no device exists with the id: %s", devID),
+					})
+					continue
+				}
+
+				// a device named in several places only has its type checked once
+				if checked[devID] {
+					continue
+				}
+				checked[devID] = true
+
+				switch {
+				case typeID == "":
+					issues = append(issues, models.LintIssue{
+						RoomID:    roomID,
+						Kind:      LintMissingDeviceType,
+						Field:     field,
+						Reference: devID,
+						Message:   fmt.Sprintf("%s has no device type", devID),
+					})
+				case !knownTypes[typeID]:
+					issues = append(issues, models.LintIssue{
+						RoomID:    roomID,
+						Kind:      LintUnknownDeviceType,
+						Field:     field,
+						Reference: devID,
+						Message:   fmt.Sprintf("%s has the device type %s, which doesn't exist", devID, typeID),
+					})
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+// deviceTypeID returns the id of the device's type, from either of the fields it can be in
+func deviceTypeID(d db.Device) string {
+	if d.Type.ID != "" {
+		return d.Type.ID
+	}
+
+	return d.TypeID
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// MicrophoneRole is the role of devices that are microphones
+const MicrophoneRole = "Microphone"
+
+// GetMicrophones returns the microphones matching the filter. Each one's
+// audio group comes from its room's ui-configuration.
+func (s *Service) GetMicrophones(f Filter) ([]models.Microphone, error) {
+	log.Log.Info("searching microphones", zap.Any("filter", f))
+
+	sel := f.selector(3)
+	if f.Search != "" {
+		sel.Search(f.Search, "_id", "name", "display_name")
+	}
+
+	devs, err := s.DB.GetDevicesWithRole(MicrophoneRole, sel)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetMicrophones get devices: %w", err)
+	}
+
+	groups := map[string]map[string]string{}
+	mics := []models.Microphone{}
+	for _, dev := range devs {
+		roomID, ok := resourceRoom(dev.ID)
+		if !ok {
+			continue
+		}
+
+		if _, ok := groups[roomID]; !ok {
+			groups[roomID], err = s.audioGroups(roomID)
+			if err != nil {
+				return nil, fmt.Errorf("services/GetMicrophones: %w", err)
+			}
+		}
+
+		mics = append(mics, microphone(dev, groups[roomID]))
+	}
+
+	return mics, nil
+}
+
+// GetMicrophoneByID returns the microphone, or nil if there isn't a microphone with the id
+func (s *Service) GetMicrophoneByID(id string) (*models.Microphone, error) {
+	log.Log.Info("getting microphone by id", zap.String("id", id))
+
+	dev, err := s.microphoneDevice(id)
+	if err != nil || dev == nil {
+		return nil, err
+	}
+
+	roomID, _ := resourceRoom(id)
+	groups, err := s.audioGroups(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetMicrophoneByID: %w", err)
+	}
+
+	mic := microphone(*dev, groups)
+	return &mic, nil
+}
+
+// GetMicrophoneState returns the microphone's volume and mute from the AV API,
+// or nil if there isn't a microphone with the id
+func (s *Service) GetMicrophoneState(id string) (*models.MicrophoneState, error) {
+	log.Log.Info("getting microphone state", zap.String("id", id))
+
+	dev, err := s.microphoneDevice(id)
+	if err != nil || dev == nil {
+		return nil, err
+	}
+
+	parts := strings.Split(id, "-")
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	if err := db.GetState(url, "GET", &room); err != nil {
+		return nil, fmt.Errorf("services/GetMicrophoneState get state: %w", err)
+	}
+
+	return s.microphoneState(id, parts[2], &room)
+}
+
+// SetMicrophoneState changes the microphone's volume or mute, returning its new
+// state, or nil if there isn't a microphone with the id
+func (s *Service) SetMicrophoneState(id string, update models.MicrophoneStateUpdate) (*models.MicrophoneState, error) {
+	log.Log.Info("setting microphone state", zap.String("id", id))
+
+	if update.Volume != nil && (*update.Volume < 0 || *update.Volume > 100) {
+		return nil, fmt.Errorf("volume must be between 0 and 100")
+	}
+
+	dev, err := s.microphoneDevice(id)
+	if err != nil || dev == nil {
+		return nil, err
+	}
+
+	parts := strings.Split(id, "-")
+	change := models.RoomStateChange{
+		AudioDevices: []models.AudioDeviceChange{
+			{Name: parts[2], Muted: update.Muted, Volume: update.Volume},
+		},
+	}
+
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	if err := db.SetState(url, "PUT", change, &room); err != nil {
+		return nil, fmt.Errorf("services/SetMicrophoneState set state: %w", err)
+	}
+
+	return s.microphoneState(id, parts[2], &room)
+}
+
+// microphoneState finds the named microphone's state in the room's state
+func (s *Service) microphoneState(id, name string, room *models.RoomState) (*models.MicrophoneState, error) {
+	i := s.findAudioIndex(name, room.AudioDevices)
+	if i == -1 {
+		return nil, fmt.Errorf("no state found for microphone: %s", id)
+	}
+
+	return &models.MicrophoneState{
+		Volume: room.AudioDevices[i].Volume,
+		Muted:  room.AudioDevices[i].Muted,
+	}, nil
+}
+
+// microphoneDevice returns the device document of the microphone, or nil if
+// there isn't a device with the id or it isn't a microphone
+func (s *Service) microphoneDevice(id string) (*db.Device, error) {
+	if _, ok := resourceRoom(id); !ok {
+		return nil, nil
+	}
+
+	dev, err := s.DB.GetDeviceByID(id)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("get device: %w", err)
+	case !dev.HasRole(MicrophoneRole):
+		return nil, nil
+	}
+
+	return dev, nil
+}
+
+// audioGroups returns the audio group each device in the room's presets is in, by device name
+func (s *Service) audioGroups(roomID string) (map[string]string, error) {
+	config, err := s.DB.GetUIConfig(roomID)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return map[string]string{}, nil
+	case err != nil:
+		return nil, fmt.Errorf("get ui config: %w", err)
+	}
+
+	groups := map[string]string{}
+	for _, p := range config.Presets {
+		for group, devices := range p.AudioGroups {
+			for _, dev := range devices {
+				groups[dev] = group
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// microphone makes a microphone out of its device document
+func microphone(dev db.Device, groups map[string]string) models.Microphone {
+	parts := strings.Split(dev.ID, "-")
+
+	name := dev.DisplayName
+	if name == "" {
+		name = dev.Name
+	}
+
+	deviceType := dev.Type.ID
+	if deviceType == "" {
+		deviceType = dev.TypeID
+	}
+
+	return models.Microphone{
+		MicrophoneID: dev.ID,
+		Name:         name,
+		RoomNum:      parts[1],
+		BldgAbbr:     parts[0],
+		DeviceType:   deviceType,
+		AudioGroup:   groups[parts[2]],
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+)
+
+// DocRange is the documents in a couch database whose ids start with Prefix.
+// An empty Prefix is every document in the database.
+type DocRange struct {
+	Database string
+	Prefix   string
+}
+
+// AllConfig is every document configuration is built from
+var AllConfig = []DocRange{
+	{Database: db.RoomsDB},
+	{Database: db.DevicesDB},
+	{Database: db.DeviceTypesDB},
+	{Database: db.UIConfigDB},
+	{Database: db.BuildingsDB},
+}
+
+// RoomConfig is the documents a room's configuration is built from. Device
+// types are included because a room's resources are described by them.
+func RoomConfig(roomID string) []DocRange {
+	return []DocRange{
+		{Database: db.RoomsDB, Prefix: roomID},
+		{Database: db.DevicesDB, Prefix: roomID + "-"},
+		{Database: db.UIConfigDB, Prefix: roomID},
+		{Database: db.DeviceTypesDB},
+	}
+}
+
+// DeviceConfig is the documents the configuration of devices whose ids start
+// with prefix is built from
+func DeviceConfig(prefix string) []DocRange {
+	return []DocRange{
+		{Database: db.DevicesDB, Prefix: prefix},
+		{Database: db.DeviceTypesDB},
+	}
+}
+
+// BuildingConfig is the documents a building's configuration is built from
+func BuildingConfig(bldgAbbr string) []DocRange {
+	return []DocRange{
+		{Database: db.BuildingsDB, Prefix: bldgAbbr},
+		{Database: db.RoomsDB, Prefix: bldgAbbr + "-"},
+		{Database: db.DevicesDB, Prefix: bldgAbbr + "-"},
+		{Database: db.DeviceTypesDB},
+	}
+}
+
+// Version returns a hash of the revisions of every document in ranges. It
+// changes whenever one of them is added, changed or deleted. A range covering
+// a whole database uses the database's update sequence instead of listing it.
+// Ranges in s.Versions are used from it rather than asking couch again.
+func (s *Service) Version(ranges ...DocRange) (string, error) {
+	h := sha256.New()
+	for _, r := range ranges {
+		v, err := s.rangeVersion(r)
+		if err != nil {
+			return "", fmt.Errorf("services/Version: %w", err)
+		}
+
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", r.Database, r.Prefix, v)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// rangeVersion returns the version of the documents in r
+func (s *Service) rangeVersion(r DocRange) (string, error) {
+	var gen uint64
+	if s.Versions != nil {
+		v, g, ok := s.Versions.get(r)
+		if ok {
+			return v, nil
+		}
+
+		gen = g
+	}
+
+	var v string
+	if r.Prefix == "" {
+		seq, err := s.DB.GetUpdateSeq(r.Database)
+		if err != nil {
+			return "", err
+		}
+
+		v = seq
+	} else {
+		revs, err := s.DB.GetRevs(r.Database, r.Prefix)
+		if err != nil {
+			return "", err
+		}
+
+		ids := make([]string, 0, len(revs))
+		for id := range revs {
+			ids = append(ids, id)
+		}
+
+		sort.Strings(ids)
+
+		h := sha256.New()
+		for _, id := range ids {
+			fmt.Fprintf(h, "%s\x00%s\n", id, revs[id])
+		}
+
+		v = hex.EncodeToString(h.Sum(nil))
+	}
+
+	if s.Versions != nil {
+		s.Versions.put(r, v, gen)
+	}
+
+	return v, nil
+}
+
+// maxCachedVersions is how many ranges VersionCache keeps. Ranges come from
+// ids in requests, so it starts over rather than growing without end.
+const maxCachedVersions = 10000
+
+// VersionCache keeps the version of each range Version is asked for until a
+// document in it changes, so configuration that hasn't changed can be tagged
+// without asking couch. It follows the _changes feed of every database in
+// AllConfig to find out, and only keeps ranges in databases whose feed it is
+// following.
+type VersionCache struct {
+	DB *db.Service
+
+	mu       sync.Mutex
+	versions map[DocRange]string
+
+	// following is the databases whose feeds are being followed, and gens
+	// counts the changes to each, so a version worked out while one of its
+	// documents changed isn't kept
+	following map[string]bool
+	gens      map[string]uint64
+}
+
+// Start follows the databases' _changes feeds until ctx is done
+func (v *VersionCache) Start(ctx context.Context) {
+	v.mu.Lock()
+	v.init()
+	v.mu.Unlock()
+
+	for _, r := range AllConfig {
+		go v.watch(ctx, r.Database)
+	}
+}
+
+// init must be called with v.mu held
+func (v *VersionCache) init() {
+	if v.versions != nil {
+		return
+	}
+
+	v.versions = map[DocRange]string{}
+	v.following = map[string]bool{}
+	v.gens = map[string]uint64{}
+}
+
+// watch follows the database's _changes feed, starting over from its current
+// sequence whenever the feed fails. Nothing in the database is cached while
+// it isn't being followed.
+func (v *VersionCache) watch(ctx context.Context, database string) {
+	for {
+		err := v.follow(ctx, database)
+		v.changed(database, "", false)
+
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, db.ErrNotFound):
+			log.Log.Debug("unable to follow couch changes", zap.String("database", database), zap.Error(err))
+		case err != nil:
+			log.Log.Warn("unable to follow couch changes", zap.String("database", database), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// follow marks the database as followed and drops the versions of ranges
+// its documents change in, until ctx is done or the feed fails
+func (v *VersionCache) follow(ctx context.Context, database string) error {
+	since, err := v.DB.GetUpdateSeq(database)
+	switch {
+	case err != nil:
+		return err
+	case since == "":
+		return db.ErrNotFound
+	}
+
+	v.changed(database, "", true)
+
+	for ctx.Err() == nil {
+		changes, next, err := v.DB.GetChanges(database, since, time.Minute)
+		if err != nil {
+			return err
+		}
+
+		since = next
+		for _, c := range changes {
+			v.changed(database, c.ID, true)
+		}
+	}
+
+	return nil
+}
+
+// changed drops the cached versions of the ranges in database that id is in,
+// or every range in it if id is empty, and records whether it is being followed
+func (v *VersionCache) changed(database, id string, following bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.init()
+	v.following[database] = following
+	v.gens[database]++
+
+	for r := range v.versions {
+		if r.Database == database && (id == "" || strings.HasPrefix(id, r.Prefix)) {
+			delete(v.versions, r)
+		}
+	}
+}
+
+// get returns the cached version of r. If there isn't one, it returns the
+// generation to give put with the version once it is worked out.
+func (v *VersionCache) get(r DocRange) (string, uint64, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.init()
+	version, ok := v.versions[r]
+	return version, v.gens[r.Database], ok
+}
+
+// put caches the version of r, unless its database isn't being followed or
+// has changed since gen
+func (v *VersionCache) put(r DocRange, version string, gen uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.init()
+	if !v.following[r.Database] || v.gens[r.Database] != gen {
+		return
+	}
+
+	if len(v.versions) >= maxCachedVersions {
+		v.versions = map[DocRange]string{}
+	}
+
+	v.versions[r] = version
+}
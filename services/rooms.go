@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"go.uber.org/zap"
@@ -12,26 +13,23 @@ import (
 	"github.com/byuoitav/uapi-translator/models"
 )
 
-func (s *Service) GetRooms(roomNum, bldgAbbr string) ([]models.Room, error) {
+// GetRooms returns every room matching the filter. Their resources are only
+// looked up if they are needed by the filter; see AddRoomResources.
+func (s *Service) GetRooms(f Filter) ([]models.Room, error) {
 	url := fmt.Sprintf("%s/rooms/_find", os.Getenv("DB_ADDRESS"))
-	var query models.RoomQuery
-
-	if roomNum != "" && bldgAbbr != "" {
-		log.Log.Info("searching rooms by room number and building abbreviation", zap.String("roomNum", roomNum), zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("%s-%s$", bldgAbbr, roomNum)
-	} else if roomNum != "" {
-		log.Log.Info("searching rooms by room number", zap.String("roomNum", roomNum))
-		query.Limit = 1000
-		query.Selector.ID.Regex = fmt.Sprintf("-%s$", roomNum)
-	} else if bldgAbbr != "" {
-		log.Log.Info("searching rooms by building abbreviation", zap.String("bldgAbbr", bldgAbbr))
-		query.Limit = 30 //Todo: get a definite answer on the limit
-		query.Selector.ID.Regex = bldgAbbr
-	} else {
-		log.Log.Info("getting all rooms")
-		query.Limit = 30 //Todo: get a definite answer on the limit
-		query.Selector.ID.GT = "\x00"
+	log.Log.Info("searching rooms", zap.Any("filter", f))
+
+	query := db.Query{
+		Selector: f.selector(2),
+		Limit:    allItems,
+	}
+
+	if len(f.Designations) > 0 {
+		query.Selector.In("designation", f.Designations...)
+	}
+
+	if f.Search != "" {
+		query.Selector.Search(f.Search, "_id", "name", "description", "tags.description")
 	}
 
 	var resp db.RoomResponse
@@ -41,17 +39,33 @@ func (s *Service) GetRooms(roomNum, bldgAbbr string) ([]models.Room, error) {
 		return nil, err
 	}
 
-	var rooms []models.Room
+	rooms := []models.Room{}
 	if resp.Docs == nil {
 		log.Log.Info("no rooms resulted from query")
 		return nil, fmt.Errorf("No rooms exist under the provided search criteria")
 	}
 	for _, rm := range resp.Docs {
 		roomParts := strings.Split(rm.ID, "-")
-		resources, err := s.GetRoomResources(rm.ID)
-		if err != nil {
-			return nil, fmt.Errorf("services/GetRooms get room resources: %w", err)
+
+		var resources []models.Resource
+		if len(f.HasResources) > 0 {
+			resources, err = s.GetRoomResources(rm.ID)
+			if err != nil {
+				return nil, fmt.Errorf("services/GetRooms get room resources: %w", err)
+			}
 		}
+
+		if len(f.HasResources) > 0 {
+			names := make([]string, len(resources))
+			for i := range resources {
+				names[i] = resources[i].Resource
+			}
+
+			if !f.hasResources(names) {
+				continue
+			}
+		}
+
 		next := models.Room{
 			RoomID:      rm.ID,
 			RoomNum:     roomParts[1],
@@ -64,36 +78,63 @@ func (s *Service) GetRooms(roomNum, bldgAbbr string) ([]models.Room, error) {
 	return rooms, nil
 }
 
+// AddRoomResources looks up the resources of each room that doesn't have them yet
+func (s *Service) AddRoomResources(rooms []models.Room) error {
+	for i := range rooms {
+		if rooms[i].Resources != nil {
+			continue
+		}
+
+		resources, err := s.GetRoomResources(rooms[i].RoomID)
+		if err != nil {
+			return fmt.Errorf("services/AddRoomResources: %w", err)
+		}
+
+		rooms[i].Resources = resources
+	}
+
+	return nil
+}
+
 func (s *Service) GetRoomDevices(roomID string) (*models.RoomDevices, error) {
 	// Check if room exists
 	roomParts := strings.Split(roomID, "-")
-	_, err := s.GetRooms(roomParts[1], roomParts[0])
+	roomFilter := Filter{BldgAbbrs: roomParts[:1], RoomNum: roomParts[1]}
+	_, err := s.GetRooms(roomFilter)
 	if err != nil {
 		return nil, fmt.Errorf("No rooms exist with the id: %s", roomID)
 	}
 
-	var devices models.RoomDevices
-	displays, err := s.GetDisplays(roomParts[1], roomParts[0])
+	devices := models.RoomDevices{
+		Displays: []string{},
+		Outputs:  []string{},
+		Inputs:   []string{},
+	}
+	displays, err := s.GetDisplays(roomFilter)
 	if err == nil {
 		for _, disp := range displays {
 			devices.Displays = append(devices.Displays, disp.DisplayID)
 		}
 	}
 
-	audioOutputs, err := s.GetAudioOutputs(roomParts[1], roomParts[0], "")
+	audioOutputs, err := s.GetAudioOutputs(roomFilter)
 	if err == nil {
 		for _, out := range audioOutputs {
 			devices.Outputs = append(devices.Outputs, out.OutputID)
 		}
 	}
 
-	inputs, err := s.GetInputs(roomParts[1], roomParts[0])
+	inputs, err := s.GetInputs(roomFilter)
 	if err == nil {
 		for _, in := range inputs {
 			devices.Inputs = append(devices.Inputs, in.DeviceID)
 		}
 	}
 
+	SortStrings(devices.Displays)
+	SortStrings(devices.Outputs)
+	SortStrings(devices.Inputs)
+
 	return &devices, nil
 }
 
@@ -106,7 +147,17 @@ func (s *Service) GetRoomResources(roomID string) ([]models.Resource, error) {
 		return nil, fmt.Errorf("services/GetRoomResources get devices: %w", err)
 	}
 
-	types := map[string]*db.DeviceType{}
+	resources, err := s.resources(devs, map[string]*db.DeviceType{})
+	if err != nil {
+		return nil, fmt.Errorf("services/GetRoomResources: %w", err)
+	}
+
+	return resources, nil
+}
+
+// resources abstracts resources from the devices, sorted by name. types caches the
+// device types that have already been pulled and is added to as more are.
+func (s *Service) resources(devs []db.Device, types map[string]*db.DeviceType) ([]models.Resource, error) {
 	resources := map[string]models.Resource{}
 
 	// Abstract resources from the devices
@@ -123,7 +174,7 @@ func (s *Service) GetRoomResources(roomID string) ([]models.Resource, error) {
 			// If we haven't pulled the type then pull it and use its description
 			t, err := s.DB.GetDeviceTypeByID(d.TypeID)
 			if err != nil {
-				return nil, fmt.Errorf("services/GetRoomResources get device type: %w", err)
+				return nil, fmt.Errorf("get device type: %w", err)
 			}
 			types[t.ID] = t
 			desc = t.Tags["description"]
@@ -160,12 +211,15 @@ func (s *Service) GetRoomResources(roomID string) ([]models.Resource, error) {
 		}
 	}
 
-	r := make([]models.Resource, len(resources))
-	i := 0
+	r := make([]models.Resource, 0, len(resources))
 	for _, resource := range resources {
-		r[i] = resource
-		i++
+		SortStrings(resource.Locations)
+		r = append(r, resource)
 	}
 
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Resource < r[j].Resource
+	})
+
 	return r, nil
 }
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/common/structs"
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// ErrSceneOutdated is returned when a scene refers to displays, inputs or
+// audio outputs the room doesn't have anymore
+var ErrSceneOutdated = errors.New("the scene no longer matches the room's configuration")
+
+// GetScenes returns the room's scenes and their version, or nil if the room doesn't exist
+func (s *Service) GetScenes(roomID string) ([]models.Scene, string, error) {
+	log.Log.Info("getting scenes", zap.String("room", roomID))
+
+	config, err := s.sceneRoom(roomID)
+	if err != nil || config == nil {
+		return nil, "", err
+	}
+
+	scenes, version, err := s.Scenes.Get(roomID)
+	if err != nil {
+		return nil, "", fmt.Errorf("services/GetScenes: %w", err)
+	}
+
+	return scenes, version, nil
+}
+
+// SetScenes replaces the room's scenes, after checking that each one only
+// changes displays, inputs and audio outputs the room has, and returns their
+// new version. check is given the version being replaced, as in scenes.Store.
+// It returns nil if the room doesn't exist.
+func (s *Service) SetScenes(roomID string, scenes []models.Scene, check func(version string) error) ([]models.Scene, string, error) {
+	log.Log.Info("setting scenes", zap.String("room", roomID), zap.Int("count", len(scenes)))
+
+	config, err := s.sceneRoom(roomID)
+	if err != nil || config == nil {
+		return nil, "", err
+	}
+
+	names := map[string]bool{}
+	for i, scene := range scenes {
+		field := fmt.Sprintf("[%d].av_scene_name", i)
+		switch {
+		case scene.Name == "":
+			return nil, "", &InvalidControlError{Field: field, Reason: "is required"}
+		case strings.Contains(scene.Name, "/"):
+			return nil, "", &InvalidControlError{Field: field, Reason: "can't contain /"}
+		case names[scene.Name]:
+			return nil, "", &InvalidControlError{Field: field, Reason: "there is already a scene named " + scene.Name}
+		}
+		names[scene.Name] = true
+
+		if len(scene.Displays) == 0 && len(scene.AudioOutputs) == 0 {
+			return nil, "", &InvalidControlError{Field: fmt.Sprintf("[%d]", i), Reason: "must change at least one display or audio output"}
+		}
+
+		if _, err := sceneChange(roomID, config.Presets, scene, fmt.Sprintf("[%d].", i)); err != nil {
+			return nil, "", err
+		}
+
+		if scenes[i].Displays == nil {
+			scenes[i].Displays = []models.SceneDisplay{}
+		}
+
+		if scenes[i].AudioOutputs == nil {
+			scenes[i].AudioOutputs = []models.SceneAudioOutput{}
+		}
+	}
+
+	version, err := s.Scenes.Put(roomID, scenes, check)
+	if err != nil {
+		return nil, "", fmt.Errorf("services/SetScenes: %w", err)
+	}
+
+	return scenes, version, nil
+}
+
+// ApplyScene makes every change in the scene with a single request to the AV
+// API, returning the room's new state. It returns nil if the room or scene
+// doesn't exist.
+func (s *Service) ApplyScene(roomID, name string) (*models.RoomResourceState, error) {
+	log.Log.Info("applying scene", zap.String("room", roomID), zap.String("scene", name))
+
+	config, err := s.sceneRoom(roomID)
+	if err != nil || config == nil {
+		return nil, err
+	}
+
+	scenes, _, err := s.Scenes.Get(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("services/ApplyScene: %w", err)
+	}
+
+	var scene *models.Scene
+	for i := range scenes {
+		if scenes[i].Name == name {
+			scene = &scenes[i]
+			break
+		}
+	}
+
+	if scene == nil {
+		return nil, nil
+	}
+
+	change, err := sceneChange(roomID, config.Presets, *scene, "")
+	if err != nil {
+		return nil, fmt.Errorf("services/ApplyScene %s: %w: %s", name, ErrSceneOutdated, err)
+	}
+
+	parts := strings.Split(roomID, "-")
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	if err := db.SetState(url, "PUT", change, &room); err != nil {
+		return nil, fmt.Errorf("services/ApplyScene set state: %w", err)
+	}
+
+	return s.roomResourceState(roomID, config.Presets, &room), nil
+}
+
+// sceneRoom returns the room's ui-configuration, or nil if the room doesn't exist
+func (s *Service) sceneRoom(roomID string) (*db.UIConfig, error) {
+	parts := strings.Split(roomID, "-")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, nil
+	}
+
+	config, err := s.DB.GetUIConfig(roomID)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("get ui config: %w", err)
+	}
+
+	return config, nil
+}
+
+// sceneChange translates the scene into a change to the room's state. prefix
+// starts the field in any error, to say which scene it is about.
+func sceneChange(roomID string, presets []structs.Preset, scene models.Scene, prefix string) (models.RoomStateChange, error) {
+	change := models.RoomStateChange{}
+
+	for i, disp := range scene.Displays {
+		field := fmt.Sprintf("%sav_displays[%d]", prefix, i)
+
+		p := presetIndex(roomID, disp.DisplayID, len(presets))
+		if p == -1 {
+			return change, &InvalidControlError{Field: field + ".av_display_id", Reason: "the room doesn't have a display " + disp.DisplayID}
+		}
+
+		var power, input *string
+		if disp.Powered != nil {
+			state := "standby"
+			if *disp.Powered {
+				state = "on"
+			}
+			power = &state
+		}
+
+		if disp.Input != nil {
+			in := strings.TrimPrefix(*disp.Input, roomID+"-")
+			if !avid.Contains(presets[p].Inputs, in) {
+				return change, &InvalidControlError{Field: field + ".av_display_input", Reason: *disp.Input + " is not an input for " + disp.DisplayID}
+			}
+			input = &in
+		}
+
+		for _, name := range presets[p].Displays {
+			change.Displays = append(change.Displays, models.DisplayChange{
+				Name:    name,
+				Power:   power,
+				Input:   input,
+				Blanked: disp.Blanked,
+			})
+		}
+	}
+
+	for i, out := range scene.AudioOutputs {
+		field := fmt.Sprintf("%sav_audio_outputs[%d]", prefix, i)
+
+		names := audioOutputDevices(roomID, out.OutputID, presets)
+		if len(names) == 0 {
+			return change, &InvalidControlError{Field: field + ".av_audio_output_id", Reason: "the room doesn't have an audio output " + out.OutputID}
+		}
+
+		if out.Volume != nil && (*out.Volume < 0 || *out.Volume > 100) {
+			return change, &InvalidControlError{Field: field + ".av_audio_output_volume_level", Reason: "must be between 0 and 100"}
+		}
+
+		for _, name := range names {
+			change.AudioDevices = append(change.AudioDevices, models.AudioDeviceChange{
+				Name:   name,
+				Muted:  out.Muted,
+				Volume: out.Volume,
+			})
+		}
+	}
+
+	return change, nil
+}
+
+// audioOutputDevices returns the names of the devices an audio output in the
+// room controls, or nil if the room doesn't have the audio output
+func audioOutputDevices(roomID, outputID string, presets []structs.Preset) []string {
+	if strings.HasPrefix(outputID, roomID+"-MasterAudio") {
+		n, err := strconv.Atoi(strings.TrimPrefix(outputID, roomID+"-MasterAudio"))
+		if err != nil || n < 1 || n > len(presets) {
+			return nil
+		}
+
+		return presets[n-1].AudioDevices
+	}
+
+	name := strings.TrimPrefix(outputID, roomID+"-")
+	for _, p := range presets {
+		if avid.Contains(p.IndependentAudioDevices, name) {
+			return []string{name}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// RunScheduleAction makes a schedule's change to every display and audio
+// output in the room with a single request to the AV API, like ApplyScene. A
+// display whose preset doesn't have the action's input is left out of the
+// change; the error describes it and anything else that failed.
+func (s *Service) RunScheduleAction(roomID string, action models.ScheduleAction) error {
+	log.Log.Info("running schedule action", zap.String("room", roomID))
+
+	config, err := s.sceneRoom(roomID)
+	switch {
+	case err != nil:
+		return fmt.Errorf("services/RunScheduleAction: %w", err)
+	case config == nil:
+		return fmt.Errorf("services/RunScheduleAction: no room exists with the id: %s", roomID)
+	}
+
+	var errs []string
+	var scene models.Scene
+	if disp := action.Display; disp != nil {
+		for i, p := range config.Presets {
+			id := fmt.Sprintf("%s-Display%d", roomID, i+1)
+			if disp.Input != nil && !avid.Contains(p.Inputs, strings.TrimPrefix(*disp.Input, roomID+"-")) {
+				errs = append(errs, fmt.Sprintf("%s: %s is not an input for the display", id, *disp.Input))
+				continue
+			}
+
+			scene.Displays = append(scene.Displays, models.SceneDisplay{
+				DisplayID: id,
+				Powered:   disp.Powered,
+				Blanked:   disp.Blanked,
+				Input:     disp.Input,
+			})
+		}
+	}
+
+	if out := action.AudioOutput; out != nil {
+		var ids []string
+		for i, p := range config.Presets {
+			if len(p.AudioDevices) > 0 {
+				ids = append(ids, fmt.Sprintf("%s-MasterAudio%d", roomID, i+1))
+			}
+
+			for _, name := range p.IndependentAudioDevices {
+				if id := roomID + "-" + name; !avid.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+		}
+
+		for _, id := range ids {
+			scene.AudioOutputs = append(scene.AudioOutputs, models.SceneAudioOutput{
+				OutputID: id,
+				Volume:   out.Volume,
+				Muted:    out.Muted,
+			})
+		}
+	}
+
+	if len(scene.Displays) > 0 || len(scene.AudioOutputs) > 0 {
+		change, err := sceneChange(roomID, config.Presets, scene, "")
+		if err != nil {
+			return fmt.Errorf("services/RunScheduleAction: %w", err)
+		}
+
+		parts := strings.Split(roomID, "-")
+		url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+		if err := db.SetState(url, "PUT", change, nil); err != nil {
+			errs = append(errs, fmt.Sprintf("set state: %s", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -1,7 +1,19 @@
 package services
 
-import "github.com/byuoitav/uapi-translator/db"
+import (
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/scenes"
+)
 
 type Service struct {
 	DB *db.Service
+
+	// Scenes stores each room's scenes
+	Scenes scenes.Store
+
+	// Versions caches configuration versions. If it is nil, couch is asked every time.
+	Versions *VersionCache
+
+	// BatchConcurrency is how many rooms' state the batch gets fetch at once
+	BatchConcurrency int
 }
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SortKey is a property to sort a collection by, named as it is in the JSON response.
+// Properties of embedded objects are named with dots, like av_display_state.av_display_powered.
+type SortKey struct {
+	Property   string
+	Descending bool
+}
+
+// ParseSort parses a list of properties to sort by. A property starting with - sorts in descending order.
+func ParseSort(list []string) []SortKey {
+	var keys []SortKey
+	for _, p := range list {
+		if strings.HasPrefix(p, "-") {
+			keys = append(keys, SortKey{Property: p[1:], Descending: true})
+		} else {
+			keys = append(keys, SortKey{Property: p})
+		}
+	}
+
+	return keys
+}
+
+// Sort stably sorts items, a slice, by the given keys. Items missing a property sort after those that have it.
+// Strings are compared naturally, so Display2 sorts before Display10.
+func Sort(items interface{}, keys []SortKey) error {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("services/Sort: %T is not a slice", items)
+	}
+
+	if len(keys) == 0 || v.Len() < 2 {
+		return nil
+	}
+
+	// compare the items as they appear in responses
+	values := make([][]interface{}, v.Len())
+	for i := range values {
+		b, err := json.Marshal(v.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("services/Sort marshal: %w", err)
+		}
+
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("services/Sort unmarshal: %w", err)
+		}
+
+		values[i] = make([]interface{}, len(keys))
+		for j, key := range keys {
+			values[i][j] = property(m, key.Property)
+		}
+	}
+
+	order := make([]int, v.Len())
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		for j, key := range keys {
+			c := compare(values[order[a]][j], values[order[b]][j])
+			if c == 0 {
+				continue
+			}
+
+			// missing values go last either way
+			if key.Descending && values[order[a]][j] != nil && values[order[b]][j] != nil {
+				c = -c
+			}
+
+			return c < 0
+		}
+
+		return false
+	})
+
+	sorted := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	for i, idx := range order {
+		sorted.Index(i).Set(v.Index(idx))
+	}
+
+	reflect.Copy(v, sorted)
+	return nil
+}
+
+// SortStrings sorts list naturally, so Display2 sorts before Display10
+func SortStrings(list []string) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return naturalCompare(list[i], list[j]) < 0
+	})
+}
+
+// property finds a (possibly dotted) property in m
+func property(m map[string]interface{}, name string) interface{} {
+	var cur interface{} = m
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		cur = obj[part]
+	}
+
+	return cur
+}
+
+// naturalCompare compares strings, treating runs of digits as numbers so
+// that Display2 sorts before Display10
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := digits(a), digits(b)
+		if da > 0 && db > 0 {
+			na, nb := strings.TrimLeft(a[:da], "0"), strings.TrimLeft(b[:db], "0")
+			if len(na) != len(nb) {
+				if len(na) < len(nb) {
+					return -1
+				}
+				return 1
+			}
+
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+
+			a, b = a[da:], b[db:]
+			continue
+		}
+
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+
+		a, b = a[1:], b[1:]
+	}
+
+	return len(a) - len(b)
+}
+
+// digits returns how many digits s starts with
+func digits(s string) int {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	return i
+}
+
+// compare orders two JSON values. nil sorts after everything else, and
+// values of different types are ordered by their type.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return naturalCompare(x, y)
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+
+			return 0
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return strings.Compare(fmt.Sprintf("%T", a), fmt.Sprintf("%T", b))
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/byuoitav/common/structs"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// GetRoomState returns the state of every display and audio output in the room,
+// making a single request to the AV API
+func (s *Service) GetRoomState(roomID string) (*models.RoomResourceState, error) {
+	log.Log.Info("getting room state", zap.String("id", roomID))
+	parts := strings.Split(roomID, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid room id")
+	}
+
+	config, err := s.DB.GetUIConfig(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetRoomState get ui config: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/buildings/%s/rooms/%s", os.Getenv("AV_API_URL"), parts[0], parts[1])
+
+	var room models.RoomState
+	err = db.GetState(url, "GET", &room)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetRoomState get state: %w", err)
+	}
+
+	return s.roomResourceState(roomID, config.Presets, &room), nil
+}
+
+// roomResourceState translates the AV API's state of a room into the state of its displays and audio outputs
+func (s *Service) roomResourceState(roomID string, presets []structs.Preset, room *models.RoomState) *models.RoomResourceState {
+	state := &models.RoomResourceState{
+		Displays:     map[string]models.DisplayState{},
+		AudioOutputs: map[string]models.AudioOutputState{},
+	}
+
+	for i, p := range presets {
+		if disp, ok := s.presetDisplayState(roomID, p, room); ok {
+			state.Displays[fmt.Sprintf("%s-Display%d", roomID, i+1)] = *disp
+		}
+
+		if len(p.AudioDevices) > 0 {
+			state.AudioOutputs[fmt.Sprintf("%s-MasterAudio%d", roomID, i+1)] = *s.presetAudioState(p, room)
+		}
+
+		for _, iad := range p.IndependentAudioDevices {
+			if out, ok := s.independentAudioState(iad, room); ok {
+				state.AudioOutputs[fmt.Sprintf("%s-%s", roomID, iad)] = *out
+			}
+		}
+	}
+
+	return state
+}
+
+// GetBuildingRoomIDs returns the id of every room in the building that has state
+func (s *Service) GetBuildingRoomIDs(bldgAbbr string) ([]string, error) {
+	ids, err := s.DB.GetRoomIDsByBuilding(bldgAbbr)
+	if err != nil {
+		return nil, fmt.Errorf("services/GetBuildingRoomIDs: %w", err)
+	}
+
+	return ids, nil
+}
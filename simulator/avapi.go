@@ -0,0 +1,281 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// AVAPI is an in memory stand-in for the AV API's room state endpoints.
+// Rooms are read with a GET and changed with a PUT, like the real AV API.
+// It also accepts camera control requests under /cameras, and devices can be
+// made unreachable with a PUT to /devices/{id}/unreachable (DELETE undoes it).
+type AVAPI struct {
+	mu    sync.RWMutex
+	rooms map[string]models.RoomState
+
+	// devices left out of their room's state, like the AV API does when it can't reach them
+	unreachable map[string]bool
+
+	// the last control request each camera was sent
+	cameras map[string]string
+}
+
+// roomChange is the body of a PUT. Only the fields that are set are changed.
+type roomChange struct {
+	Displays     []displayChange `json:"displays"`
+	AudioDevices []audioChange   `json:"audioDevices"`
+}
+
+type displayChange struct {
+	Name    string  `json:"name"`
+	Power   *string `json:"power"`
+	Input   *string `json:"input"`
+	Blanked *bool   `json:"blanked"`
+}
+
+type audioChange struct {
+	Name   string  `json:"name"`
+	Power  *string `json:"power"`
+	Input  *string `json:"input"`
+	Muted  *bool   `json:"muted"`
+	Volume *int    `json:"volume"`
+}
+
+// NewAVAPI returns an AVAPI without any rooms
+func NewAVAPI() *AVAPI {
+	return &AVAPI{
+		rooms:       map[string]models.RoomState{},
+		cameras:     map[string]string{},
+		unreachable: map[string]bool{},
+	}
+}
+
+// SetRoom replaces the state of the given room (in {BLDG}-{Room} format)
+func (a *AVAPI) SetRoom(roomID string, state models.RoomState) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rooms[roomID] = state
+}
+
+// Room returns the current state of the given room
+func (a *AVAPI) Room(roomID string) (models.RoomState, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	state, ok := a.rooms[roomID]
+	return state, ok
+}
+
+// SetUnreachable makes the device (in {BLDG}-{Room}-{Device} format) missing
+// from its room's state until it is set reachable again
+func (a *AVAPI) SetUnreachable(deviceID string, unreachable bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if unreachable {
+		a.unreachable[deviceID] = true
+	} else {
+		delete(a.unreachable, deviceID)
+	}
+}
+
+// reachable returns the room's state without its unreachable devices
+func (a *AVAPI) reachable(roomID string, state models.RoomState) models.RoomState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var result models.RoomState
+
+	for _, disp := range state.Displays {
+		if !a.unreachable[roomID+"-"+disp.Name] {
+			result.Displays = append(result.Displays, disp)
+		}
+	}
+
+	for _, dev := range state.AudioDevices {
+		if !a.unreachable[roomID+"-"+dev.Name] {
+			result.AudioDevices = append(result.AudioDevices, dev)
+		}
+	}
+
+	return result
+}
+
+// CameraCommand returns the last control request the camera was sent, like "zoom/in"
+func (a *AVAPI) CameraCommand(cameraID string) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	command, ok := a.cameras[cameraID]
+	return command, ok
+}
+
+func (a *AVAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// /cameras/{camera}/{command...}
+	if parts[0] == "cameras" {
+		a.serveCamera(w, r, parts[1:])
+		return
+	}
+
+	// /devices/{device}/unreachable
+	if len(parts) == 3 && parts[0] == "devices" && parts[2] == "unreachable" {
+		switch r.Method {
+		case http.MethodPut:
+			a.SetUnreachable(parts[1], true)
+		case http.MethodDelete:
+			a.SetUnreachable(parts[1], false)
+		default:
+			http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// /buildings/{bldg}/rooms/{room}
+	if len(parts) != 4 || parts[0] != "buildings" || parts[2] != "rooms" {
+		http.Error(w, "unsupported path", http.StatusNotFound)
+		return
+	}
+
+	roomID := fmt.Sprintf("%s-%s", parts[1], parts[3])
+
+	switch r.Method {
+	case http.MethodGet:
+		state, ok := a.Room(roomID)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no state for %s", roomID), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, a.reachable(roomID, state))
+	case http.MethodPut:
+		var change roomChange
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		state, err := a.apply(roomID, change)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, a.reachable(roomID, state))
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveCamera records a camera control request
+func (a *AVAPI) serveCamera(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) < 2 {
+		http.Error(w, "unsupported path", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.mu.Lock()
+	a.cameras[parts[0]] = strings.Join(parts[1:], "/")
+	a.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// apply updates the room's devices named in change
+func (a *AVAPI) apply(roomID string, change roomChange) (models.RoomState, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state, ok := a.rooms[roomID]
+	if !ok {
+		return state, fmt.Errorf("no state for %s", roomID)
+	}
+
+	// copy so readers of the old state aren't affected
+	displays := append([]models.StateDisplay{}, state.Displays...)
+	audio := append([]models.StateAudioDevice{}, state.AudioDevices...)
+
+	for _, c := range change.Displays {
+		i := findDisplay(displays, c.Name)
+		if i == -1 {
+			return state, fmt.Errorf("%s has no display %s", roomID, c.Name)
+		}
+
+		if c.Power != nil {
+			displays[i].Power = *c.Power
+		}
+		if c.Input != nil {
+			displays[i].Input = *c.Input
+		}
+		if c.Blanked != nil {
+			displays[i].Blanked = *c.Blanked
+		}
+	}
+
+	for _, c := range change.AudioDevices {
+		i := findAudioDevice(audio, c.Name)
+		if i == -1 {
+			return state, fmt.Errorf("%s has no audio device %s", roomID, c.Name)
+		}
+
+		if c.Power != nil {
+			audio[i].Power = *c.Power
+		}
+		if c.Input != nil {
+			audio[i].Input = *c.Input
+		}
+		if c.Muted != nil {
+			audio[i].Muted = *c.Muted
+		}
+		if c.Volume != nil {
+			if *c.Volume < 0 || *c.Volume > 100 {
+				return state, fmt.Errorf("volume for %s must be between 0 and 100", c.Name)
+			}
+
+			audio[i].Volume = *c.Volume
+		}
+	}
+
+	state = models.RoomState{
+		Displays:     displays,
+		AudioDevices: audio,
+	}
+	a.rooms[roomID] = state
+
+	return state, nil
+}
+
+func findDisplay(displays []models.StateDisplay, name string) int {
+	for i := range displays {
+		if displays[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func findAudioDevice(devices []models.StateAudioDevice, name string) int {
+	for i := range devices {
+		if devices[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
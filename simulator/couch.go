@@ -0,0 +1,462 @@
+package simulator
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Couch is an in memory stand-in for the couch databases the translator reads from.
+// It supports database info, _find, _all_docs, _changes and getting/putting single documents.
+type Couch struct {
+	mu  sync.RWMutex
+	dbs map[string]*database
+
+	// updated is closed (and replaced) every time a document changes
+	updated chan struct{}
+}
+
+type database struct {
+	docs map[string]map[string]interface{}
+
+	// changes holds the latest change for each document, in sequence order
+	changes []change
+	seq     int
+}
+
+type change struct {
+	Seq int
+	ID  string
+	Rev string
+}
+
+type findRequest struct {
+	Selector map[string]interface{} `json:"selector"`
+	Fields   []string               `json:"fields"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+}
+
+type findResponse struct {
+	Docs     []map[string]interface{} `json:"docs"`
+	Bookmark string                   `json:"bookmark"`
+	Warning  string                   `json:"warning,omitempty"`
+}
+
+type allDocsResponse struct {
+	TotalRows int          `json:"total_rows"`
+	Offset    int          `json:"offset"`
+	Rows      []allDocsRow `json:"rows"`
+}
+
+type allDocsRow struct {
+	ID    string                 `json:"id,omitempty"`
+	Key   string                 `json:"key"`
+	Value *revValue              `json:"value,omitempty"`
+	Doc   map[string]interface{} `json:"doc,omitempty"`
+	Error string                 `json:"error,omitempty"`
+}
+
+type revValue struct {
+	Rev string `json:"rev"`
+}
+
+type changesResponse struct {
+	Results []changeRow `json:"results"`
+	LastSeq string      `json:"last_seq"`
+	Pending int         `json:"pending"`
+}
+
+type changeRow struct {
+	Seq     string                 `json:"seq"`
+	ID      string                 `json:"id"`
+	Changes []revValue             `json:"changes"`
+	Doc     map[string]interface{} `json:"doc,omitempty"`
+}
+
+type dbInfo struct {
+	DBName    string `json:"db_name"`
+	DocCount  int    `json:"doc_count"`
+	UpdateSeq string `json:"update_seq"`
+}
+
+type putResponse struct {
+	OK  bool   `json:"ok"`
+	ID  string `json:"id"`
+	Rev string `json:"rev"`
+}
+
+type couchError struct {
+	Error  string `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// NewCouch returns an empty Couch
+func NewCouch() *Couch {
+	return &Couch{
+		dbs:     map[string]*database{},
+		updated: make(chan struct{}),
+	}
+}
+
+// Put adds doc to the given database, replacing any document with the same _id.
+// The document's _rev is set from its contents.
+func (c *Couch) Put(db string, doc map[string]interface{}) error {
+	_, err := c.put(db, doc, false)
+	return err
+}
+
+// put stores doc, checking its _rev against the current revision if checkRev is set
+func (c *Couch) put(db string, doc map[string]interface{}, checkRev bool) (string, error) {
+	id, ok := doc["_id"].(string)
+	if !ok || id == "" {
+		return "", fmt.Errorf("simulator/Put document in %s has no _id", db)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.dbs[db]
+	if !ok {
+		d = &database{docs: map[string]map[string]interface{}{}}
+		c.dbs[db] = d
+	}
+
+	gen := 1
+	prev, exists := d.docs[id]
+	if exists {
+		fmt.Sscanf(prev["_rev"].(string), "%d-", &gen)
+		gen++
+	}
+
+	if checkRev {
+		rev, _ := doc["_rev"].(string)
+		if (exists && rev != prev["_rev"]) || (!exists && rev != "") {
+			return "", errConflict
+		}
+	}
+
+	delete(doc, "_rev")
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("simulator/Put marshal %s: %w", id, err)
+	}
+
+	rev := fmt.Sprintf("%d-%x", gen, md5.Sum(b))
+	doc["_rev"] = rev
+	d.docs[id] = doc
+
+	// couch only reports the latest change for each document
+	for i := range d.changes {
+		if d.changes[i].ID == id {
+			d.changes = append(d.changes[:i], d.changes[i+1:]...)
+			break
+		}
+	}
+
+	d.seq++
+	d.changes = append(d.changes, change{Seq: d.seq, ID: id, Rev: rev})
+
+	close(c.updated)
+	c.updated = make(chan struct{})
+
+	return rev, nil
+}
+
+var errConflict = errors.New("Document update conflict.")
+
+func (c *Couch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.Trim(r.URL.Path, "/"), "/", 2)
+	if parts[0] == "" {
+		writeJSON(w, http.StatusBadRequest, couchError{Error: "bad_request", Reason: "unsupported path"})
+		return
+	}
+
+	db, doc := parts[0], ""
+	if len(parts) == 2 {
+		doc = parts[1]
+	}
+
+	c.mu.RLock()
+	d, ok := c.dbs[db]
+	var info dbInfo
+	if ok {
+		info = dbInfo{DBName: db, DocCount: len(d.docs), UpdateSeq: strconv.Itoa(d.seq)}
+	}
+	c.mu.RUnlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, couchError{Error: "not_found", Reason: "Database does not exist."})
+		return
+	}
+
+	switch {
+	case doc == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, info)
+	case doc == "_find" && r.Method == http.MethodPost:
+		c.find(w, r, db)
+	case doc == "_all_docs" && (r.Method == http.MethodGet || r.Method == http.MethodPost):
+		c.allDocs(w, r, db)
+	case doc == "_changes" && r.Method == http.MethodGet:
+		c.changes(w, r, db)
+	case !strings.HasPrefix(doc, "_") && r.Method == http.MethodGet:
+		c.mu.RLock()
+		d, ok := c.dbs[db].docs[doc]
+		c.mu.RUnlock()
+
+		if !ok {
+			writeJSON(w, http.StatusNotFound, couchError{Error: "not_found", Reason: "missing"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, d)
+	case !strings.HasPrefix(doc, "_") && r.Method == http.MethodPut:
+		var d map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			writeJSON(w, http.StatusBadRequest, couchError{Error: "bad_request", Reason: err.Error()})
+			return
+		}
+
+		d["_id"] = doc
+		rev, err := c.put(db, d, true)
+		switch {
+		case err == errConflict:
+			writeJSON(w, http.StatusConflict, couchError{Error: "conflict", Reason: err.Error()})
+		case err != nil:
+			writeJSON(w, http.StatusBadRequest, couchError{Error: "bad_request", Reason: err.Error()})
+		default:
+			writeJSON(w, http.StatusCreated, putResponse{OK: true, ID: doc, Rev: rev})
+		}
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, couchError{Error: "method_not_allowed", Reason: "unsupported request"})
+	}
+}
+
+func (c *Couch) find(w http.ResponseWriter, r *http.Request, db string) {
+	var req findRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, couchError{Error: "bad_request", Reason: err.Error()})
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 25
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := c.dbs[db].docs
+
+	res := findResponse{Docs: []map[string]interface{}{}}
+	for _, id := range sortedIDs(docs) {
+		ok, err := matches(req.Selector, docs[id])
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, couchError{Error: "invalid_selector", Reason: err.Error()})
+			return
+		}
+
+		if !ok {
+			continue
+		}
+
+		if req.Skip > 0 {
+			req.Skip--
+			continue
+		}
+
+		res.Docs = append(res.Docs, project(docs[id], req.Fields))
+		if len(res.Docs) == req.Limit {
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// project returns only the given top level fields of doc
+func project(doc map[string]interface{}, fields []string) map[string]interface{} {
+	if len(fields) == 0 {
+		return doc
+	}
+
+	p := map[string]interface{}{}
+	for _, f := range fields {
+		if v, ok := doc[f]; ok {
+			p[f] = v
+		}
+	}
+
+	return p
+}
+
+func (c *Couch) allDocs(w http.ResponseWriter, r *http.Request, db string) {
+	q := r.URL.Query()
+	includeDocs := q.Get("include_docs") == "true"
+
+	var keys []string
+	if r.Method == http.MethodPost {
+		var body struct {
+			Keys []string `json:"keys"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, couchError{Error: "bad_request", Reason: err.Error()})
+			return
+		}
+
+		keys = body.Keys
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := c.dbs[db].docs
+	res := allDocsResponse{TotalRows: len(docs), Rows: []allDocsRow{}}
+
+	row := func(id string) allDocsRow {
+		doc, ok := docs[id]
+		if !ok {
+			return allDocsRow{Key: id, Error: "not_found"}
+		}
+
+		row := allDocsRow{ID: id, Key: id, Value: &revValue{Rev: doc["_rev"].(string)}}
+		if includeDocs {
+			row.Doc = doc
+		}
+
+		return row
+	}
+
+	if keys != nil {
+		for _, k := range keys {
+			res.Rows = append(res.Rows, row(k))
+		}
+
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	// keys are JSON encoded in couch's query string
+	startKey, endKey := jsonString(q.Get("startkey")), jsonString(q.Get("endkey"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	skip, _ := strconv.Atoi(q.Get("skip"))
+	res.Offset = skip
+
+	for _, id := range sortedIDs(docs) {
+		if (startKey != "" && id < startKey) || (endKey != "" && id > endKey) {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		res.Rows = append(res.Rows, row(id))
+		if limit > 0 && len(res.Rows) == limit {
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (c *Couch) changes(w http.ResponseWriter, r *http.Request, db string) {
+	q := r.URL.Query()
+	includeDocs := q.Get("include_docs") == "true"
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	timeout := 60 * time.Second
+	if ms, err := strconv.Atoi(q.Get("timeout")); err == nil {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	c.mu.RLock()
+	since := 0
+	switch s := q.Get("since"); s {
+	case "", "0":
+	case "now":
+		since = c.dbs[db].seq
+	default:
+		// sequences look like "12" or "12-abc"
+		fmt.Sscanf(s, "%d", &since)
+	}
+	c.mu.RUnlock()
+
+	deadline := time.After(timeout)
+	for {
+		c.mu.RLock()
+		d := c.dbs[db]
+		updated := c.updated
+
+		res := changesResponse{Results: []changeRow{}, LastSeq: strconv.Itoa(since)}
+		for i, ch := range d.changes {
+			if ch.Seq <= since {
+				continue
+			}
+
+			if limit > 0 && len(res.Results) == limit {
+				res.Pending = len(d.changes) - i
+				break
+			}
+
+			row := changeRow{Seq: strconv.Itoa(ch.Seq), ID: ch.ID, Changes: []revValue{{Rev: ch.Rev}}}
+			if includeDocs {
+				row.Doc = d.docs[ch.ID]
+			}
+
+			res.Results = append(res.Results, row)
+			res.LastSeq = row.Seq
+		}
+		c.mu.RUnlock()
+
+		if len(res.Results) > 0 || q.Get("feed") != "longpoll" {
+			writeJSON(w, http.StatusOK, res)
+			return
+		}
+
+		select {
+		case <-updated:
+		case <-deadline:
+			writeJSON(w, http.StatusOK, res)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// sortedIDs returns the ids of docs in the order couch's _id index would
+func sortedIDs(docs map[string]map[string]interface{}) []string {
+	ids := make([]string, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// jsonString decodes a JSON encoded string, returning s itself if it isn't one
+func jsonString(s string) string {
+	var str string
+	if err := json.Unmarshal([]byte(s), &str); err != nil {
+		return s
+	}
+
+	return str
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
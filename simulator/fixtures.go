@@ -0,0 +1,77 @@
+package simulator
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// DefaultFixtures returns a small campus used by the contract tests and as
+// the simulator's default data set
+func DefaultFixtures() fs.FS {
+	sub, err := fs.Sub(fixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
+
+// Load fills couch and avapi from a fixtures directory laid out as:
+//
+//	couch/{database}.json  a JSON array of documents for the database
+//	state/{BLDG}-{Room}.json  the AV API state for the room
+func Load(fsys fs.FS, couch *Couch, avapi *AVAPI) error {
+	dbs, err := fs.Glob(fsys, "couch/*.json")
+	if err != nil {
+		return fmt.Errorf("simulator/Load: %w", err)
+	}
+
+	for _, file := range dbs {
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("simulator/Load read %s: %w", file, err)
+		}
+
+		var docs []map[string]interface{}
+		if err := json.Unmarshal(b, &docs); err != nil {
+			return fmt.Errorf("simulator/Load parse %s: %w", file, err)
+		}
+
+		db := strings.TrimSuffix(path.Base(file), ".json")
+		for _, doc := range docs {
+			if err := couch.Put(db, doc); err != nil {
+				return fmt.Errorf("simulator/Load %s: %w", file, err)
+			}
+		}
+	}
+
+	states, err := fs.Glob(fsys, "state/*.json")
+	if err != nil {
+		return fmt.Errorf("simulator/Load: %w", err)
+	}
+
+	for _, file := range states {
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("simulator/Load read %s: %w", file, err)
+		}
+
+		var state models.RoomState
+		if err := json.Unmarshal(b, &state); err != nil {
+			return fmt.Errorf("simulator/Load parse %s: %w", file, err)
+		}
+
+		avapi.SetRoom(strings.TrimSuffix(path.Base(file), ".json"), state)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package simulator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// matches reports whether doc satisfies a couch mango selector. Only the
+// operators the translator uses are supported.
+func matches(selector map[string]interface{}, doc map[string]interface{}) (bool, error) {
+	for field, cond := range selector {
+		var ok bool
+		var err error
+
+		switch field {
+		case "$and", "$or":
+			ok, err = combine(field, cond, doc)
+		default:
+			ok, err = matchField(lookup(doc, field), cond)
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func combine(op string, cond interface{}, doc map[string]interface{}) (bool, error) {
+	list, ok := cond.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s requires an array", op)
+	}
+
+	for _, c := range list {
+		sel, ok := c.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s requires an array of selectors", op)
+		}
+
+		ok, err := matches(sel, doc)
+		if err != nil {
+			return false, err
+		}
+
+		if op == "$or" && ok {
+			return true, nil
+		}
+
+		if op == "$and" && !ok {
+			return false, nil
+		}
+	}
+
+	return op == "$and", nil
+}
+
+// lookup finds a (possibly dotted) field in doc
+func lookup(doc map[string]interface{}, field string) interface{} {
+	var cur interface{} = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		cur = m[part]
+	}
+
+	return cur
+}
+
+func matchField(val, cond interface{}) (bool, error) {
+	ops, ok := cond.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(val, cond), nil
+	}
+
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = reflect.DeepEqual(val, arg)
+		case "$ne":
+			ok = !reflect.DeepEqual(val, arg)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = compare(op, val, arg)
+		case "$exists":
+			ok = (val != nil) == (arg == true)
+		case "$in":
+			list, _ := arg.([]interface{})
+			for _, item := range list {
+				if reflect.DeepEqual(val, item) {
+					ok = true
+					break
+				}
+			}
+		case "$regex":
+			str, isStr := val.(string)
+			pattern, _ := arg.(string)
+
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, fmt.Errorf("invalid $regex %q: %w", pattern, err)
+			}
+
+			ok = isStr && re.MatchString(str)
+		case "$elemMatch":
+			list, _ := val.([]interface{})
+			for _, item := range list {
+				m, err := matchField(item, arg)
+				if err != nil {
+					return false, err
+				}
+
+				if m {
+					ok = true
+					break
+				}
+			}
+		default:
+			// nested object selector, e.g. {"type": {"_id": {...}}}
+			if strings.HasPrefix(op, "$") {
+				return false, fmt.Errorf("unsupported operator %s", op)
+			}
+
+			m, isMap := val.(map[string]interface{})
+			if !isMap {
+				return false, nil
+			}
+
+			var err error
+			ok, err = matchField(m[op], arg)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func compare(op string, val, arg interface{}) bool {
+	var c int
+	switch v := val.(type) {
+	case string:
+		a, ok := arg.(string)
+		if !ok {
+			return false
+		}
+
+		c = strings.Compare(v, a)
+	case float64:
+		a, ok := arg.(float64)
+		if !ok {
+			return false
+		}
+
+		switch {
+		case v < a:
+			c = -1
+		case v > a:
+			c = 1
+		}
+	default:
+		return false
+	}
+
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	case "$lte":
+		return c <= 0
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"go.uber.org/zap"
+)
+
+// Headers sent with each delivery
+const (
+	HeaderEvent     = "X-UAPI-Event"
+	HeaderDelivery  = "X-UAPI-Delivery"
+	HeaderSignature = "X-UAPI-Signature"
+)
+
+// dispatch delivers the event to every webhook it matches
+func (m *Manager) dispatch(e models.WebhookEvent) {
+	m.mu.Lock()
+	var hooks []models.Webhook
+	for _, hook := range m.hooks {
+		if matches(hook.Filters, e) {
+			hooks = append(hooks, hook)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, hook := range hooks {
+		go m.deliver(hook, avid.Random(16), e)
+	}
+}
+
+// deliver sends the event to the webhook, retrying with exponential
+// backoff until it succeeds or runs out of attempts
+func (m *Manager) deliver(hook models.Webhook, deliveryID string, e models.WebhookEvent) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		logError("unable to marshal webhook event", err)
+		return
+	}
+
+	maxAttempts := m.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 6
+	}
+
+	backoff := m.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	maxBackoff := m.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Minute
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = m.send(hook, deliveryID, e.Type, body)
+		if err == nil {
+			log.Log.Debug("delivered webhook", zap.String("webhook", hook.WebhookID), zap.String("delivery", deliveryID), zap.Int("attempt", attempt))
+			return
+		}
+
+		log.Log.Info("webhook delivery failed", zap.String("webhook", hook.WebhookID), zap.String("delivery", deliveryID), zap.Int("attempt", attempt), zap.Error(err))
+
+		if attempt >= maxAttempts {
+			m.addDeadLetter(models.DeadLetter{
+				DeliveryID: deliveryID,
+				WebhookID:  hook.WebhookID,
+				Event:      e,
+				Attempts:   attempt,
+				LastError:  err.Error(),
+				FailedAt:   time.Now().UTC(),
+			})
+			return
+		}
+
+		select {
+		case <-m.done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		// stop if the webhook was deleted or changed while waiting
+		current, err := m.Get(hook.WebhookID)
+		if err != nil {
+			return
+		}
+		hook = current
+	}
+}
+
+// send makes a single delivery attempt
+func (m *Manager) send(hook models.Webhook, deliveryID, eventType string, body []byte) error {
+	m.sem <- struct{}{}
+	defer func() { <-m.sem }()
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+
+	client := m.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// drain so the connection can be reused
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("got status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the signature header value for body: the hex encoded
+// HMAC-SHA256 of the body using the webhook's secret, prefixed with sha256=
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// done returns a channel closed when the manager stops
+func (m *Manager) done() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ctx == nil {
+		return nil
+	}
+
+	return m.ctx.Done()
+}
+
+func logError(msg string, err error) {
+	log.Log.Error(msg, zap.Error(err))
+}
+
+// Publish sends an event from outside the manager, like a device health
+// alert, to every webhook whose filters match it
+func (m *Manager) Publish(e models.WebhookEvent) {
+	if e.EventID == "" {
+		e.EventID = avid.Random(16)
+	}
+
+	m.dispatch(e)
+}
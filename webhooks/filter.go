@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// matches reports whether the event passes every filter that is set
+func matches(f models.WebhookFilters, e models.WebhookEvent) bool {
+	if len(f.BldgAbbrs) > 0 && !avid.Contains(f.BldgAbbrs, e.BldgAbbr) {
+		return false
+	}
+
+	if len(f.RoomIDs) > 0 && !avid.Contains(f.RoomIDs, e.RoomID) {
+		return false
+	}
+
+	if len(f.ResourceTypes) > 0 && !avid.Contains(f.ResourceTypes, e.ResourceType) {
+		return false
+	}
+
+	if len(f.Attributes) == 0 {
+		return true
+	}
+
+	// only state changes have attributes
+	for _, attr := range f.Attributes {
+		name, value, hasValue := cut(attr, "=")
+		if !avid.Contains(e.Attributes, name) {
+			continue
+		}
+
+		if !hasValue {
+			return true
+		}
+
+		b, err := json.Marshal(e.Current[name])
+		if err != nil {
+			continue
+		}
+
+		// strings can be given with or without quotes
+		if string(b) == value || string(b) == `"`+value+`"` {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wantsState reports whether a webhook with the filters could match state changes
+func wantsState(f models.WebhookFilters) bool {
+	return len(f.ResourceTypes) == 0 || avid.Contains(f.ResourceTypes, ResourceDisplay) || avid.Contains(f.ResourceTypes, ResourceAudioOutput)
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
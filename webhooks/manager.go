@@ -0,0 +1,443 @@
+// Package webhooks notifies registered URLs of changes to room state (from
+// diffing AV API state) and configuration (from couch's _changes feeds).
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/events"
+	"github.com/byuoitav/uapi-translator/models"
+)
+
+// Resource types events can be filtered by
+const (
+	ResourceDisplay     = "display"
+	ResourceAudioOutput = "audio_output"
+	ResourceRoom        = "room"
+	ResourceDevice      = "device"
+)
+
+// ErrNotFound is returned when a webhook or dead letter doesn't exist
+var ErrNotFound = errors.New("The requested webhook was not found")
+
+// ErrInvalid is matched by errors caused by a bad webhook registration
+var ErrInvalid = errors.New("invalid webhook")
+
+// InvalidError describes what is wrong with a webhook registration
+type InvalidError struct {
+	Field  string
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid webhook: %s %s", e.Field, e.Reason)
+}
+
+func (e *InvalidError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// maxDeadLetters is how many failed deliveries are kept for each webhook
+const maxDeadLetters = 100
+
+// RoomLister finds the rooms whose state may need to be watched
+type RoomLister interface {
+	GetBuildingRoomIDs(bldgAbbr string) ([]string, error)
+}
+
+// ChangeFeed provides couch's _changes feed
+type ChangeFeed interface {
+	GetChanges(database, since string, timeout time.Duration) ([]db.Change, string, error)
+}
+
+// Manager keeps track of webhooks and delivers events to them
+type Manager struct {
+	// Path is the file webhooks and dead letters are saved in. If it is
+	// empty they are only kept in memory.
+	Path string
+
+	Hub     *events.Hub
+	Rooms   RoomLister
+	Changes ChangeFeed
+
+	// Databases are the couch databases watched for configuration changes
+	Databases []string
+
+	// MaxAttempts is how many times a delivery is tried before it is dead lettered
+	MaxAttempts int
+
+	// Backoff is how long to wait before the first retry. It doubles each attempt, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	Client *http.Client
+
+	mu          sync.Mutex
+	hooks       map[string]models.Webhook
+	deadLetters map[string][]models.DeadLetter
+
+	ctx     context.Context
+	refresh chan struct{}
+	sem     chan struct{}
+
+	// stopChanges stops following the _changes feeds, which are only followed while a webhook exists
+	stopChanges context.CancelFunc
+}
+
+// file is what is saved at Path
+type file struct {
+	Webhooks    []models.Webhook    `json:"webhooks"`
+	DeadLetters []models.DeadLetter `json:"dead_letters"`
+}
+
+// Load reads saved webhooks from Path, if it exists
+func (m *Manager) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	if m.Path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(m.Path)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("webhooks/Load read: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("webhooks/Load unmarshal: %w", err)
+	}
+
+	for _, hook := range f.Webhooks {
+		m.hooks[hook.WebhookID] = hook
+	}
+
+	for _, dl := range f.DeadLetters {
+		m.deadLetters[dl.WebhookID] = append(m.deadLetters[dl.WebhookID], dl)
+	}
+
+	return nil
+}
+
+// Start watches for changes and delivers them until ctx is done
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	m.init()
+	m.ctx = ctx
+	m.watchConfig()
+	m.mu.Unlock()
+
+	go m.watchState(ctx)
+}
+
+// init must be called with m.mu held
+func (m *Manager) init() {
+	if m.hooks != nil {
+		return
+	}
+
+	m.hooks = map[string]models.Webhook{}
+	m.deadLetters = map[string][]models.DeadLetter{}
+	m.refresh = make(chan struct{}, 1)
+	m.sem = make(chan struct{}, 16)
+}
+
+// List returns every webhook, oldest first
+func (m *Manager) List() []models.Webhook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hooks := []models.Webhook{}
+	for _, hook := range m.hooks {
+		hooks = append(hooks, hook)
+	}
+
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].Created.Equal(hooks[j].Created) {
+			return hooks[i].WebhookID < hooks[j].WebhookID
+		}
+		return hooks[i].Created.Before(hooks[j].Created)
+	})
+
+	return hooks
+}
+
+// Get returns the webhook with the given id
+func (m *Manager) Get(id string) (models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hook, ok := m.hooks[id]
+	if !ok {
+		return hook, ErrNotFound
+	}
+
+	return hook, nil
+}
+
+// Create registers a new webhook. A secret is generated if one isn't given.
+func (m *Manager) Create(in models.WebhookInput) (models.Webhook, error) {
+	if err := validate(in); err != nil {
+		return models.Webhook{}, err
+	}
+
+	if in.Secret == "" {
+		in.Secret = avid.Random(32)
+	}
+
+	hook := models.Webhook{
+		WebhookID: avid.Random(16),
+		URL:       in.URL,
+		Filters:   in.Filters,
+		Secret:    in.Secret,
+		Created:   time.Now().UTC(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+	m.hooks[hook.WebhookID] = hook
+	if err := m.save(); err != nil {
+		delete(m.hooks, hook.WebhookID)
+		return models.Webhook{}, err
+	}
+
+	m.refreshRooms()
+	m.watchConfig()
+	return hook, nil
+}
+
+// Update replaces the url and filters of a webhook, and its secret if one is given.
+// If check isn't nil, it is given the current webhook and the update is only
+// made if it returns nil, which is checked while no other change can be made.
+func (m *Manager) Update(id string, in models.WebhookInput, check func(models.Webhook) error) (models.Webhook, error) {
+	if err := validate(in); err != nil {
+		return models.Webhook{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, ok := m.hooks[id]
+	if !ok {
+		return prev, ErrNotFound
+	}
+
+	if check != nil {
+		if err := check(prev); err != nil {
+			return models.Webhook{}, err
+		}
+	}
+
+	hook := prev
+	hook.URL = in.URL
+	hook.Filters = in.Filters
+	if in.Secret != "" {
+		hook.Secret = in.Secret
+	}
+
+	m.hooks[id] = hook
+	if err := m.save(); err != nil {
+		m.hooks[id] = prev
+		return models.Webhook{}, err
+	}
+
+	m.refreshRooms()
+	return hook, nil
+}
+
+// Delete removes a webhook and its dead letters. check is used like it is by Update.
+func (m *Manager) Delete(id string, check func(models.Webhook) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hook, ok := m.hooks[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if check != nil {
+		if err := check(hook); err != nil {
+			return err
+		}
+	}
+
+	dls := m.deadLetters[id]
+	delete(m.hooks, id)
+	delete(m.deadLetters, id)
+
+	if err := m.save(); err != nil {
+		m.hooks[id] = hook
+		m.deadLetters[id] = dls
+		return err
+	}
+
+	m.refreshRooms()
+	m.watchConfig()
+	return nil
+}
+
+// DeadLetters returns the deliveries to the webhook that failed, oldest first
+func (m *Manager) DeadLetters(id string) ([]models.DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.hooks[id]; !ok {
+		return nil, ErrNotFound
+	}
+
+	return append([]models.DeadLetter{}, m.deadLetters[id]...), nil
+}
+
+// Redeliver removes a dead letter and tries to deliver it again
+func (m *Manager) Redeliver(id, deliveryID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hook, ok := m.hooks[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	dls := m.deadLetters[id]
+	for i := range dls {
+		if dls[i].DeliveryID != deliveryID {
+			continue
+		}
+
+		m.deadLetters[id] = append(dls[:i:i], dls[i+1:]...)
+		if err := m.save(); err != nil {
+			m.deadLetters[id] = dls
+			return err
+		}
+
+		go m.deliver(hook, deliveryID, dls[i].Event)
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// addDeadLetter records a failed delivery, if the webhook still exists
+func (m *Manager) addDeadLetter(dl models.DeadLetter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.hooks[dl.WebhookID]; !ok {
+		return
+	}
+
+	dls := append(m.deadLetters[dl.WebhookID], dl)
+	if len(dls) > maxDeadLetters {
+		dls = dls[len(dls)-maxDeadLetters:]
+	}
+
+	m.deadLetters[dl.WebhookID] = dls
+	if err := m.save(); err != nil {
+		logError("unable to save dead letter", err)
+	}
+}
+
+// save must be called with m.mu held
+func (m *Manager) save() error {
+	if m.Path == "" {
+		return nil
+	}
+
+	f := file{
+		Webhooks:    []models.Webhook{},
+		DeadLetters: []models.DeadLetter{},
+	}
+
+	for _, hook := range m.hooks {
+		f.Webhooks = append(f.Webhooks, hook)
+	}
+
+	for _, dls := range m.deadLetters {
+		f.DeadLetters = append(f.DeadLetters, dls...)
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("webhooks/save marshal: %w", err)
+	}
+
+	// write then rename so a crash doesn't leave a partial file
+	tmp := m.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("webhooks/save write: %w", err)
+	}
+
+	if err := os.Rename(tmp, m.Path); err != nil {
+		return fmt.Errorf("webhooks/save rename: %w", err)
+	}
+
+	return nil
+}
+
+// refreshRooms must be called with m.mu held
+func (m *Manager) refreshRooms() {
+	select {
+	case m.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// watchConfig starts following the _changes feeds when there is a webhook to
+// send configuration changes to, and stops once there isn't. It must be called with m.mu held.
+func (m *Manager) watchConfig() {
+	switch {
+	case m.ctx == nil:
+	case len(m.hooks) > 0 && m.stopChanges == nil:
+		ctx, cancel := context.WithCancel(m.ctx)
+		m.stopChanges = cancel
+
+		for _, database := range m.Databases {
+			go m.watchChanges(ctx, database)
+		}
+	case len(m.hooks) == 0 && m.stopChanges != nil:
+		m.stopChanges()
+		m.stopChanges = nil
+	}
+}
+
+func validate(in models.WebhookInput) error {
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &InvalidError{Field: "url", Reason: "must be an absolute http or https url"}
+	}
+
+	for _, t := range in.Filters.ResourceTypes {
+		switch t {
+		case ResourceDisplay, ResourceAudioOutput, ResourceRoom, ResourceDevice:
+		default:
+			return &InvalidError{Field: "filters.resource_types", Reason: fmt.Sprintf("has unknown resource type %q", t)}
+		}
+	}
+
+	// state changes are found by polling each room, so they can't be asked for from every room at once
+	if wantsState(in.Filters) && len(in.Filters.RoomIDs) == 0 && len(in.Filters.BldgAbbrs) == 0 {
+		return &InvalidError{Field: "filters", Reason: "must have av_room_ids or building_abbreviations, unless resource_types only has room or device"}
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byuoitav/uapi-translator/avid"
+	"github.com/byuoitav/uapi-translator/db"
+	"github.com/byuoitav/uapi-translator/events"
+	"github.com/byuoitav/uapi-translator/log"
+	"github.com/byuoitav/uapi-translator/models"
+	"go.uber.org/zap"
+)
+
+// roomRefreshInterval is how often the set of watched rooms is rebuilt, to pick up new rooms
+const roomRefreshInterval = 10 * time.Minute
+
+// watchState subscribes to the state of every room a webhook could be
+// interested in, turning changes to a resource's attributes into events
+func (m *Manager) watchState(ctx context.Context) {
+	var sub *events.Subscription
+	var rooms []string
+	var lastID uint64
+
+	// last is the previous state of each resource, as sent in events
+	last := map[string]map[string]interface{}{}
+
+	ticker := time.NewTicker(roomRefreshInterval)
+	defer ticker.Stop()
+
+	defer func() {
+		if sub != nil {
+			sub.Close()
+		}
+	}()
+
+	resubscribe := func() {
+		next, err := m.stateRooms()
+		if err != nil {
+			logError("unable to find rooms to watch for webhooks", err)
+			return
+		}
+
+		if sub != nil && avid.Equal(next, rooms) {
+			return
+		}
+
+		if sub != nil {
+			sub.Close()
+			sub = nil
+		}
+
+		rooms = next
+		if len(rooms) == 0 {
+			return
+		}
+
+		log.Log.Info("watching room state for webhooks", zap.Int("rooms", len(rooms)))
+		sub = m.Hub.Subscribe(rooms, lastID)
+		for _, e := range sub.Backlog {
+			lastID = e.ID
+			m.stateChanged(e, last)
+		}
+	}
+
+	resubscribe()
+
+	for {
+		var c <-chan events.Event
+		if sub != nil {
+			c = sub.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.refresh:
+			resubscribe()
+		case <-ticker.C:
+			resubscribe()
+		case e, ok := <-c:
+			if !ok {
+				// the hub dropped us for falling behind
+				sub = nil
+				resubscribe()
+				continue
+			}
+
+			lastID = e.ID
+			m.stateChanged(e, last)
+		}
+	}
+}
+
+// stateRooms returns the rooms whose state webhooks may want, sorted
+func (m *Manager) stateRooms() ([]string, error) {
+	m.mu.Lock()
+	var filters []models.WebhookFilters
+	for _, hook := range m.hooks {
+		if wantsState(hook.Filters) {
+			filters = append(filters, hook.Filters)
+		}
+	}
+	m.mu.Unlock()
+
+	set := map[string]bool{}
+	for _, f := range filters {
+		switch {
+		case len(f.RoomIDs) > 0:
+			for _, id := range f.RoomIDs {
+				if len(f.BldgAbbrs) == 0 || avid.Contains(f.BldgAbbrs, avid.Building(id)) {
+					set[id] = true
+				}
+			}
+		case len(f.BldgAbbrs) > 0:
+			for _, bldg := range f.BldgAbbrs {
+				ids, err := m.Rooms.GetBuildingRoomIDs(bldg)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, id := range ids {
+					set[id] = true
+				}
+			}
+		}
+	}
+
+	rooms := make([]string, 0, len(set))
+	for id := range set {
+		rooms = append(rooms, id)
+	}
+
+	sort.Strings(rooms)
+	return rooms, nil
+}
+
+// stateChanged diffs a state event against the resource's last known state.
+// The first state seen for a resource is only recorded.
+func (m *Manager) stateChanged(e events.Event, last map[string]map[string]interface{}) {
+	current, err := toMap(e.Data)
+	if err != nil {
+		logError("unable to read state event", err)
+		return
+	}
+
+	var resourceType string
+	switch e.Type {
+	case events.TypeDisplay:
+		resourceType = ResourceDisplay
+		delete(current, "av_display_id")
+	case events.TypeAudioOutput:
+		resourceType = ResourceAudioOutput
+		delete(current, "av_audio_output_id")
+	}
+
+	prev, ok := last[e.ResourceID]
+	last[e.ResourceID] = current
+	if !ok {
+		return
+	}
+
+	var changed []string
+	for k, v := range current {
+		if p, ok := prev[k]; !ok || !jsonEqual(p, v) {
+			changed = append(changed, k)
+		}
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	sort.Strings(changed)
+
+	m.dispatch(models.WebhookEvent{
+		EventID:      avid.Random(16),
+		Type:         resourceType + ".state_changed",
+		Time:         e.Time.UTC(),
+		BldgAbbr:     avid.Building(e.RoomID),
+		RoomID:       e.RoomID,
+		ResourceType: resourceType,
+		ResourceID:   e.ResourceID,
+		Attributes:   changed,
+		Previous:     prev,
+		Current:      current,
+	})
+}
+
+// watchChanges follows a couch database's _changes feed, sending an event for each changed document
+func (m *Manager) watchChanges(ctx context.Context, database string) {
+	since := "now"
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		changes, next, err := m.Changes.GetChanges(database, since, time.Minute)
+		if err != nil {
+			log.Log.Warn("unable to get couch changes", zap.String("database", database), zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+
+		since = next
+		for _, c := range changes {
+			m.configChanged(database, c)
+		}
+	}
+}
+
+func (m *Manager) configChanged(database string, c db.Change) {
+	if strings.HasPrefix(c.ID, "_design/") {
+		return
+	}
+
+	e := models.WebhookEvent{
+		EventID:    avid.Random(16),
+		Time:       time.Now().UTC(),
+		BldgAbbr:   avid.Building(c.ID),
+		RoomID:     c.ID,
+		ResourceID: c.ID,
+		Database:   database,
+		Rev:        c.Rev(),
+		Deleted:    c.Deleted,
+	}
+
+	switch database {
+	case db.DevicesDB:
+		e.Type = "device.changed"
+		e.ResourceType = ResourceDevice
+		e.RoomID = avid.Room(c.ID)
+	default:
+		e.Type = "room.changed"
+		e.ResourceType = ResourceRoom
+
+		// the room may be new, or have new displays and audio outputs
+		m.mu.Lock()
+		m.refreshRooms()
+		m.mu.Unlock()
+	}
+
+	m.dispatch(e)
+}
+
+// toMap converts v to the generic form it would have as JSON
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+	return string(ab) == string(bb)
+}